@@ -0,0 +1,276 @@
+package ct
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	ct "github.com/google/certificate-transparency/go"
+	"github.com/google/certificate-transparency/go/tls"
+	"github.com/google/certificate-transparency/go/x509"
+)
+
+// batchSigExtensionOID identifies, in spirit, the SCT extension (documented
+// as batch_sig_v1: OID 1.3.6.1.4.1.11129.2.4.100) that a BatchSigner uses in
+// place of a plain per-entry signature: it carries the batch root signature
+// plus this submission's inclusion proof into that root. CTExtensions has
+// no OID-tagged container upstream, so the extension is just the
+// serializeBatchSigV1Extension bytes below, with the OID recorded here for
+// documentation and for any out-of-band negotiation a log needs to do.
+var batchSigExtensionOID = []int{1, 3, 6, 1, 4, 1, 11129, 2, 4, 100}
+
+// batchSigV1Extension is the structure serialized into the batch_sig_v1 SCT
+// extension.
+type batchSigV1Extension struct {
+	// RootSignature is the KeyManager's single signature over the
+	// batch's Merkle root hash.
+	RootSignature ct.DigitallySigned
+	// LeafIndex is this submission's position among the batch's leaves.
+	LeafIndex uint64
+	// TreeSize is the number of leaves in the batch.
+	TreeSize uint64
+	// AuditPath is the inclusion proof from this leaf to the batch root.
+	AuditPath [][32]byte
+}
+
+// serializeBatchSigV1Extension encodes ext using the same length-prefixed
+// wire format as the rest of this package's CT structures.
+func serializeBatchSigV1Extension(ext batchSigV1Extension) ([]byte, error) {
+	var buf tlsBuffer
+	buf.writeUint8(uint8(ext.RootSignature.Algorithm.Hash))
+	buf.writeUint8(uint8(ext.RootSignature.Algorithm.Signature))
+	if err := buf.writeUint16Opaque(ext.RootSignature.Signature); err != nil {
+		return nil, err
+	}
+	buf.writeUint64(ext.LeafIndex)
+	buf.writeUint64(ext.TreeSize)
+	buf.writeUint8(uint8(len(ext.AuditPath)))
+	for _, sibling := range ext.AuditPath {
+		buf.writeRaw(sibling[:])
+	}
+	return buf.Bytes(), nil
+}
+
+// parseBatchSigV1Extension decodes what serializeBatchSigV1Extension wrote;
+// used by tests (and by monitors) to check a batch_sig_v1 SCT inclusion
+// proof.
+func parseBatchSigV1Extension(data []byte) (batchSigV1Extension, error) {
+	if len(data) < 4 {
+		return batchSigV1Extension{}, fmt.Errorf("ct: batch_sig_v1 extension too short")
+	}
+	var ext batchSigV1Extension
+	ext.RootSignature.Algorithm = tls.SignatureAndHashAlgorithm{
+		Hash:      tls.HashAlgorithm(data[0]),
+		Signature: tls.SignatureAlgorithm(data[1]),
+	}
+	pos := 2
+	sigLen := int(data[pos])<<8 | int(data[pos+1])
+	pos += 2
+	if len(data) < pos+sigLen+8+8+1 {
+		return batchSigV1Extension{}, fmt.Errorf("ct: batch_sig_v1 extension truncated")
+	}
+	ext.RootSignature.Signature = data[pos : pos+sigLen]
+	pos += sigLen
+
+	ext.LeafIndex = beUint64(data[pos:])
+	pos += 8
+	ext.TreeSize = beUint64(data[pos:])
+	pos += 8
+
+	pathLen := int(data[pos])
+	pos++
+	for i := 0; i < pathLen; i++ {
+		if len(data) < pos+32 {
+			return batchSigV1Extension{}, fmt.Errorf("ct: batch_sig_v1 extension audit path truncated")
+		}
+		var sibling [32]byte
+		copy(sibling[:], data[pos:pos+32])
+		ext.AuditPath = append(ext.AuditPath, sibling)
+		pos += 32
+	}
+	return ext, nil
+}
+
+func beUint64(b []byte) uint64 {
+	var v uint64
+	for i := 0; i < 8; i++ {
+		v = v<<8 | uint64(b[i])
+	}
+	return v
+}
+
+// BatchSignerOptions configures a BatchSigner.
+type BatchSignerOptions struct {
+	// Window is how long a BatchSigner waits to coalesce concurrent
+	// submissions before closing the batch and signing its root. Typical
+	// values are 5-50ms.
+	Window time.Duration
+	// Enabled selects batch signing. When false, Sign{Certificate,
+	// Precertificate} fall back to a plain per-entry signature via
+	// signV1SCTFor{Certificate,Precertificate}, with no batch_sig_v1
+	// extension.
+	Enabled bool
+}
+
+// BatchSigner coalesces concurrent add-chain/add-pre-chain submissions
+// within a small time window into one KeyManager.Sign call: it builds an
+// RFC 6962 Merkle tree over the submissions' SCT signature-input blobs,
+// signs the root once, and hands each submitter an SCT carrying that root
+// signature and its own inclusion proof in a batch_sig_v1 extension.
+//
+// A batch-mode SCT's Signature is over the batch root, not over this
+// submission's own signature input, so it cannot be checked with
+// LogVerifier.VerifyV1SCTForCertificate/VerifyV1SCTForPrecertificate: those
+// rebuild a plain per-entry input from sct.Extensions and verify Signature
+// against that directly. Checking a batch-mode SCT instead means parsing
+// its batch_sig_v1 extension (parseBatchSigV1Extension) and walking
+// AuditPath from this entry's own leaf hash up to the root that
+// RootSignature covers.
+type BatchSigner struct {
+	km   KeyManager
+	opts BatchSignerOptions
+
+	mu      sync.Mutex
+	pending []*batchRequest
+	timer   *time.Timer
+}
+
+type batchRequest struct {
+	entryType     ct.LogEntryType
+	certDER       []byte
+	issuerKeyHash [32]byte
+	tbs           []byte
+	timestamp     uint64
+	done          chan batchResult
+}
+
+type batchResult struct {
+	leaf ct.MerkleTreeLeaf
+	sct  ct.SignedCertificateTimestamp
+	err  error
+}
+
+// NewBatchSigner creates a BatchSigner that signs through km.
+func NewBatchSigner(km KeyManager, opts BatchSignerOptions) *BatchSigner {
+	return &BatchSigner{km: km, opts: opts}
+}
+
+// SignCertificate signs cert, batching the KeyManager call with any other
+// submissions that arrive within the configured window.
+func (b *BatchSigner) SignCertificate(cert *x509.Certificate, t time.Time) (ct.MerkleTreeLeaf, ct.SignedCertificateTimestamp, error) {
+	if !b.opts.Enabled {
+		return signV1SCTForCertificate(b.km, cert, t)
+	}
+	return b.submit(&batchRequest{
+		entryType: ct.X509LogEntryType,
+		certDER:   cert.Raw,
+		timestamp: timeToMillis(t),
+	})
+}
+
+// SignPrecertificate signs the precertificate chain[0], batching the
+// KeyManager call with any other submissions that arrive within the
+// configured window. chain is the full submitted chain, needed to resolve
+// IssuerKeyHash correctly when chain[1] is an RFC 6962 section 3.1
+// pre-issuer.
+func (b *BatchSigner) SignPrecertificate(chain []*x509.Certificate, t time.Time) (ct.MerkleTreeLeaf, ct.SignedCertificateTimestamp, error) {
+	if !b.opts.Enabled {
+		return signV1SCTForPrecertificate(b.km, chain, t)
+	}
+	issuerKeyHash, tbs, err := precertIssuerKeyHashAndTBS(chain)
+	if err != nil {
+		return ct.MerkleTreeLeaf{}, ct.SignedCertificateTimestamp{}, err
+	}
+	return b.submit(&batchRequest{
+		entryType:     ct.PrecertLogEntryType,
+		issuerKeyHash: issuerKeyHash,
+		tbs:           tbs,
+		timestamp:     timeToMillis(t),
+	})
+}
+
+// submit enqueues req, arranging for the batch to be flushed after
+// b.opts.Window (starting the timer if req is the first request in a new
+// batch), then blocks for this submission's result.
+func (b *BatchSigner) submit(req *batchRequest) (ct.MerkleTreeLeaf, ct.SignedCertificateTimestamp, error) {
+	req.done = make(chan batchResult, 1)
+
+	b.mu.Lock()
+	b.pending = append(b.pending, req)
+	if b.timer == nil {
+		b.timer = time.AfterFunc(b.opts.Window, b.flush)
+	}
+	b.mu.Unlock()
+
+	res := <-req.done
+	return res.leaf, res.sct, res.err
+}
+
+// flush closes the current batch, signs its root once, and delivers a
+// result (leaf, SCT or error) to every pending request.
+func (b *BatchSigner) flush() {
+	b.mu.Lock()
+	batch := b.pending
+	b.pending = nil
+	b.timer = nil
+	b.mu.Unlock()
+
+	if len(batch) == 0 {
+		return
+	}
+
+	leaves := make([][]byte, len(batch))
+	merkleLeaves := make([]ct.MerkleTreeLeaf, len(batch))
+	for i, req := range batch {
+		merkleLeaves[i] = buildV1MerkleTreeLeaf(req.entryType, req.timestamp, req.certDER, req.issuerKeyHash, req.tbs)
+		input, err := serializeV1SCTSignatureInput(req.entryType, req.timestamp, req.certDER, req.issuerKeyHash, req.tbs, ct.CTExtensions{})
+		if err != nil {
+			deliverErr(batch, fmt.Errorf("failed to serialize signature input: %v", err))
+			return
+		}
+		leaves[i] = input
+	}
+
+	tree := buildMerkleTree(leaves)
+	root := tree.root()
+
+	rootSig, err := signDigitally(b.km, root)
+	if err != nil {
+		deliverErr(batch, fmt.Errorf("failed to sign batch root: %v", err))
+		return
+	}
+
+	logID, err := logIDFromKeyManager(b.km)
+	if err != nil {
+		deliverErr(batch, err)
+		return
+	}
+
+	for i, req := range batch {
+		ext, err := serializeBatchSigV1Extension(batchSigV1Extension{
+			RootSignature: rootSig,
+			LeafIndex:     uint64(i),
+			TreeSize:      uint64(len(batch)),
+			AuditPath:     tree.auditPath(i),
+		})
+		if err != nil {
+			req.done <- batchResult{err: fmt.Errorf("failed to marshal batch_sig_v1 extension: %v", err)}
+			continue
+		}
+
+		sct := ct.SignedCertificateTimestamp{
+			SCTVersion: ct.V1,
+			LogID:      ct.LogID{KeyID: logID},
+			Timestamp:  req.timestamp,
+			Extensions: ct.CTExtensions(ext),
+			Signature:  rootSig,
+		}
+		req.done <- batchResult{leaf: merkleLeaves[i], sct: sct}
+	}
+}
+
+func deliverErr(batch []*batchRequest, err error) {
+	for _, req := range batch {
+		req.done <- batchResult{err: err}
+	}
+}
@@ -0,0 +1,195 @@
+package ct
+
+import (
+	"crypto/sha256"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	ct "github.com/google/certificate-transparency/go"
+	"github.com/google/certificate-transparency/go/fixchain"
+	"github.com/google/trillian/examples/ct/testonly"
+)
+
+func TestBatchSignerCoalescesSubmissions(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	cert, err := fixchain.CertificateFromPEM(testonly.LeafSignedByFakeIntermediateCertPem)
+	if err != nil {
+		t.Fatalf("failed to set up test cert: %v", err)
+	}
+
+	km := NewMockKeyManager(mockCtrl)
+	km.EXPECT().Sign(gomock.Any()).Return([]byte("root-signed"), nil).Times(1)
+	km.EXPECT().GetRawPublicKey().AnyTimes().Return(fixedPublicKeyDER, nil)
+	km.EXPECT().SignatureAlgorithm().AnyTimes().Return(SignatureAlgorithmRSA)
+
+	b := NewBatchSigner(km, BatchSignerOptions{Window: 20 * time.Millisecond, Enabled: true})
+
+	const n = 8
+	var wg sync.WaitGroup
+	scts := make([]struct {
+		ext batchSigV1Extension
+		err error
+	}, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, sct, err := b.SignCertificate(cert, fixedTime)
+			if err != nil {
+				scts[i].err = err
+				return
+			}
+			ext, err := parseBatchSigV1Extension(sct.Extensions)
+			scts[i].ext = ext
+			scts[i].err = err
+		}(i)
+	}
+	wg.Wait()
+
+	seenIndices := make(map[uint64]bool)
+	for i, s := range scts {
+		if s.err != nil {
+			t.Fatalf("submission %d failed: %v", i, s.err)
+		}
+		if s.ext.TreeSize != n {
+			t.Fatalf("submission %d: got tree size %d, want %d", i, s.ext.TreeSize, n)
+		}
+		seenIndices[s.ext.LeafIndex] = true
+	}
+	if len(seenIndices) != n {
+		t.Fatalf("got %d distinct leaf indices, want %d (one KM call must still give each submission a unique position)", len(seenIndices), n)
+	}
+}
+
+// rootFromAuditPath recomputes a Merkle root from a leaf hash, its index
+// and an inclusion proof, using the same combining rule buildMerkleTree
+// used to build the tree (an index's parity at each level determines which
+// side of the pair it was on). This only reproduces buildMerkleTree's root
+// when the tree size is a power of two, as in the tests below: for other
+// sizes auditPath omits the levels where a node has no sibling, and this
+// walk does not account for those levels.
+func rootFromAuditPath(leafHash [32]byte, index uint64, path [][32]byte) [32]byte {
+	h := leafHash
+	for _, sibling := range path {
+		if index%2 == 1 {
+			h = rfc6962NodeHash(sibling, h)
+		} else {
+			h = rfc6962NodeHash(h, sibling)
+		}
+		index /= 2
+	}
+	return h
+}
+
+func TestBatchSignerAuditPathProvesInclusionInSignedRoot(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	cert, err := fixchain.CertificateFromPEM(testonly.LeafSignedByFakeIntermediateCertPem)
+	if err != nil {
+		t.Fatalf("failed to set up test cert: %v", err)
+	}
+
+	km := newRealRSAKeyManager(t)
+	v, err := NewLogVerifier(km.pub)
+	if err != nil {
+		t.Fatalf("NewLogVerifier failed: %v", err)
+	}
+
+	b := NewBatchSigner(km, BatchSignerOptions{Window: 20 * time.Millisecond, Enabled: true})
+
+	const n = 8
+	var wg sync.WaitGroup
+	scts := make([]ct.SignedCertificateTimestamp, n)
+	errs := make([]error, n)
+	for i := 0; i < n; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			_, sct, err := b.SignCertificate(cert, fixedTime)
+			scts[i] = sct
+			errs[i] = err
+		}(i)
+	}
+	wg.Wait()
+
+	input, err := serializeV1SCTSignatureInput(ct.X509LogEntryType, timeToMillis(fixedTime), cert.Raw, [32]byte{}, nil, ct.CTExtensions{})
+	if err != nil {
+		t.Fatalf("failed to build leaf signature input: %v", err)
+	}
+	leafHash := rfc6962LeafHash(input)
+
+	for i, sct := range scts {
+		if errs[i] != nil {
+			t.Fatalf("submission %d failed: %v", i, errs[i])
+		}
+		ext, err := parseBatchSigV1Extension(sct.Extensions)
+		if err != nil {
+			t.Fatalf("submission %d: failed to parse batch_sig_v1 extension: %v", i, err)
+		}
+		root := rootFromAuditPath(leafHash, ext.LeafIndex, ext.AuditPath)
+		if err := v.verifyDigitallySigned(root[:], ext.RootSignature); err != nil {
+			t.Fatalf("submission %d: root reconstructed from its audit path does not verify against RootSignature: %v", i, err)
+		}
+	}
+}
+
+func TestBatchSignerFallsBackWhenDisabled(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	cert, err := fixchain.CertificateFromPEM(testonly.LeafSignedByFakeIntermediateCertPem)
+	if err != nil {
+		t.Fatalf("failed to set up test cert: %v", err)
+	}
+
+	input, err := serializeV1SCTSignatureInput(ct.X509LogEntryType, timeToMillis(fixedTime), cert.Raw, [32]byte{}, nil, ct.CTExtensions{})
+	if err != nil {
+		t.Fatalf("failed to build expected signature input: %v", err)
+	}
+	digest := sha256.Sum256(input)
+	km := setupMockKeyManager(mockCtrl, digest[:])
+
+	b := NewBatchSigner(km, BatchSignerOptions{Window: time.Hour, Enabled: false})
+	_, sct, err := b.SignCertificate(cert, fixedTime)
+	if err != nil {
+		t.Fatalf("SignCertificate (disabled) failed: %v", err)
+	}
+	if len(sct.Extensions) != 0 {
+		t.Fatalf("got %d bytes of SCT extensions, want 0 (batching is disabled)", len(sct.Extensions))
+	}
+}
+
+func BenchmarkBatchSignerThroughput(b *testing.B) {
+	mockCtrl := gomock.NewController(b)
+	defer mockCtrl.Finish()
+
+	cert, err := fixchain.CertificateFromPEM(testonly.LeafSignedByFakeIntermediateCertPem)
+	if err != nil {
+		b.Fatalf("failed to set up test cert: %v", err)
+	}
+
+	km := NewMockKeyManager(mockCtrl)
+	km.EXPECT().Sign(gomock.Any()).Return([]byte("root-signed"), nil).AnyTimes()
+	km.EXPECT().GetRawPublicKey().AnyTimes().Return(fixedPublicKeyDER, nil)
+	km.EXPECT().SignatureAlgorithm().AnyTimes().Return(SignatureAlgorithmRSA)
+
+	signer := NewBatchSigner(km, BatchSignerOptions{Window: 5 * time.Millisecond, Enabled: true})
+
+	b.ResetTimer()
+	var wg sync.WaitGroup
+	for i := 0; i < b.N; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, _, err := signer.SignCertificate(cert, fixedTime); err != nil {
+				b.Error(err)
+			}
+		}()
+	}
+	wg.Wait()
+}
@@ -0,0 +1,171 @@
+package ct
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	ct "github.com/google/certificate-transparency/go"
+)
+
+// WitnessCosignature is a single witness's signature over a log's STH, as
+// used by the witness-cosigned checkpoint model (e.g. the C2SP
+// "checkpoint" / "tlog-witness" schemes).
+type WitnessCosignature struct {
+	// WitnessKeyID identifies the witness that produced Signature.
+	WitnessKeyID string
+	// Signature is the witness's Ed25519 signature over the log's
+	// serialized STH bytes.
+	Signature []byte
+	// Timestamp is when the witness produced Signature.
+	Timestamp time.Time
+}
+
+// CosignedSTH is a log's STH together with whatever witness cosignatures
+// have been collected for it.
+type CosignedSTH struct {
+	STH          ct.SignedTreeHead
+	Cosignatures []WitnessCosignature
+}
+
+// WitnessClient talks to a single witness.
+type WitnessClient interface {
+	// Add asks the witness to cosign sth, returning its cosignature.
+	Add(ctx context.Context, sth ct.SignedTreeHead) (WitnessCosignature, error)
+	// GetLatest returns the newest STH (and any cosignatures already on
+	// it) that the witness has seen from this log.
+	GetLatest(ctx context.Context) (CosignedSTH, error)
+}
+
+// CosigningStateManager periodically pushes the log's freshly signed STH to
+// a configured set of witnesses, accumulating their cosignatures, and
+// serves the result to the get-sth-cosigned handler.
+type CosigningStateManager struct {
+	// witnesses maps witness key ID to the client used to reach it.
+	witnesses map[string]WitnessClient
+	// pushInterval is how often RunForever pushes the latest STH to the
+	// witness set.
+	pushInterval time.Duration
+	// witnessTimeout bounds how long a single witness gets to respond to
+	// Add before it is treated as unreachable for that round.
+	witnessTimeout time.Duration
+
+	mu      sync.Mutex
+	current CosignedSTH
+}
+
+// NewCosigningStateManager creates a manager that cosigns through the given
+// witnesses.
+func NewCosigningStateManager(witnesses map[string]WitnessClient, pushInterval, witnessTimeout time.Duration) *CosigningStateManager {
+	return &CosigningStateManager{
+		witnesses:      witnesses,
+		pushInterval:   pushInterval,
+		witnessTimeout: witnessTimeout,
+	}
+}
+
+// RunForever pushes sths to the witness set every pushInterval, until ctx is
+// done.
+func (m *CosigningStateManager) RunForever(ctx context.Context, sths <-chan ct.SignedTreeHead) {
+	ticker := time.NewTicker(m.pushInterval)
+	defer ticker.Stop()
+
+	var latest ct.SignedTreeHead
+	haveSTH := false
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case sth := <-sths:
+			latest = sth
+			haveSTH = true
+		case <-ticker.C:
+			if haveSTH {
+				m.cosign(ctx, latest)
+			}
+		}
+	}
+}
+
+// cosign pushes sth to every configured witness in parallel, collects
+// whichever cosignatures come back within witnessTimeout, and merges them
+// by witness key into the current CosignedSTH: once the tree head strictly
+// advances, the old size's cosignatures are dropped, but repeated rounds at
+// the same TreeSize accumulate rather than replace each other, so a round
+// that gets fewer responses doesn't discard cosignatures collected earlier
+// for that same STH.
+func (m *CosigningStateManager) cosign(ctx context.Context, sth ct.SignedTreeHead) {
+	ctx, cancel := context.WithTimeout(ctx, m.witnessTimeout)
+	defer cancel()
+
+	type result struct {
+		keyID string
+		cs    WitnessCosignature
+		err   error
+	}
+	results := make(chan result, len(m.witnesses))
+	for keyID, w := range m.witnesses {
+		keyID, w := keyID, w
+		go func() {
+			cs, err := w.Add(ctx, sth)
+			results <- result{keyID: keyID, cs: cs, err: err}
+		}()
+	}
+
+	byKey := make(map[string]WitnessCosignature)
+	for range m.witnesses {
+		r := <-results
+		if r.err != nil {
+			continue
+		}
+		byKey[r.keyID] = r.cs
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	switch {
+	case sth.TreeSize > m.current.STH.TreeSize:
+		// The tree head advanced: cosignatures collected for the old,
+		// now-superseded size no longer apply.
+		m.current = CosignedSTH{STH: sth}
+	case sth.TreeSize < m.current.STH.TreeSize:
+		// A stale round for an STH we've already moved past; nothing to
+		// merge it into.
+		return
+	default:
+		// Same TreeSize as before: keep what's already installed and
+		// merge this round's results into it below.
+		m.current.STH = sth
+	}
+
+	existing := make(map[string]bool, len(m.current.Cosignatures))
+	for _, cs := range m.current.Cosignatures {
+		existing[cs.WitnessKeyID] = true
+	}
+	for keyID, cs := range byKey {
+		if existing[keyID] {
+			// Replace rather than duplicate: a witness that re-answers
+			// for the same STH only ever counts once.
+			for i, existingCS := range m.current.Cosignatures {
+				if existingCS.WitnessKeyID == keyID {
+					m.current.Cosignatures[i] = cs
+					break
+				}
+			}
+			continue
+		}
+		m.current.Cosignatures = append(m.current.Cosignatures, cs)
+	}
+}
+
+// GetSTHCosigned implements the get-sth-cosigned handler: it returns
+// whatever CosignedSTH is currently installed.
+func (m *CosigningStateManager) GetSTHCosigned() (CosignedSTH, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.current.STH.TreeSize == 0 && len(m.current.Cosignatures) == 0 {
+		return CosignedSTH{}, fmt.Errorf("ct: no cosigned STH available yet")
+	}
+	return m.current, nil
+}
@@ -0,0 +1,145 @@
+package ct
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	ct "github.com/google/certificate-transparency/go"
+)
+
+func sth(size uint64) ct.SignedTreeHead {
+	return ct.SignedTreeHead{TreeSize: size, Timestamp: timeToMillis(fixedTime)}
+}
+
+func TestCosigningStateManagerPartialQuorum(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	good := NewMockWitnessClient(mockCtrl)
+	good.EXPECT().Add(gomock.Any(), gomock.Any()).Return(WitnessCosignature{WitnessKeyID: "good", Signature: []byte("sig-good")}, nil)
+
+	bad := NewMockWitnessClient(mockCtrl)
+	bad.EXPECT().Add(gomock.Any(), gomock.Any()).Return(WitnessCosignature{}, context.DeadlineExceeded)
+
+	m := NewCosigningStateManager(map[string]WitnessClient{"good": good, "bad": bad}, time.Minute, time.Second)
+	m.cosign(context.Background(), sth(10))
+
+	got, err := m.GetSTHCosigned()
+	if err != nil {
+		t.Fatalf("GetSTHCosigned failed: %v", err)
+	}
+	if len(got.Cosignatures) != 1 {
+		t.Fatalf("got %d cosignatures, want 1 (only the responsive witness)", len(got.Cosignatures))
+	}
+	if got.Cosignatures[0].WitnessKeyID != "good" {
+		t.Fatalf("got cosignature from %q, want %q", got.Cosignatures[0].WitnessKeyID, "good")
+	}
+}
+
+func TestCosigningStateManagerWitnessTimeout(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	slow := NewMockWitnessClient(mockCtrl)
+	slow.EXPECT().Add(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, _ ct.SignedTreeHead) (WitnessCosignature, error) {
+		<-ctx.Done()
+		return WitnessCosignature{}, ctx.Err()
+	})
+
+	m := NewCosigningStateManager(map[string]WitnessClient{"slow": slow}, time.Minute, 10*time.Millisecond)
+	m.cosign(context.Background(), sth(10))
+
+	got, err := m.GetSTHCosigned()
+	if err != nil {
+		t.Fatalf("GetSTHCosigned failed: %v", err)
+	}
+	if len(got.Cosignatures) != 0 {
+		t.Fatalf("got %d cosignatures, want 0 (witness should have timed out)", len(got.Cosignatures))
+	}
+}
+
+func TestCosigningStateManagerDropsStaleCosignatures(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	w := NewMockWitnessClient(mockCtrl)
+	w.EXPECT().Add(gomock.Any(), gomock.Any()).Return(WitnessCosignature{WitnessKeyID: "w", Signature: []byte("sig-10")}, nil)
+
+	m := NewCosigningStateManager(map[string]WitnessClient{"w": w}, time.Minute, time.Second)
+	m.cosign(context.Background(), sth(10))
+
+	got, err := m.GetSTHCosigned()
+	if err != nil {
+		t.Fatalf("GetSTHCosigned failed: %v", err)
+	}
+	if got.STH.TreeSize != 10 {
+		t.Fatalf("got tree size %d, want 10", got.STH.TreeSize)
+	}
+
+	// The tree head advances, but this round no witness responds in
+	// time; the cosignatures collected for size 10 must not survive.
+	w2 := NewMockWitnessClient(mockCtrl)
+	w2.EXPECT().Add(gomock.Any(), gomock.Any()).Return(WitnessCosignature{}, context.DeadlineExceeded)
+	m.witnesses = map[string]WitnessClient{"w": w2}
+	m.cosign(context.Background(), sth(20))
+
+	got, err = m.GetSTHCosigned()
+	if err != nil {
+		t.Fatalf("GetSTHCosigned failed: %v", err)
+	}
+	if got.STH.TreeSize != 20 {
+		t.Fatalf("got tree size %d, want 20 (tree head should have advanced)", got.STH.TreeSize)
+	}
+	if len(got.Cosignatures) != 0 {
+		t.Fatalf("got %d cosignatures carried over from the stale STH, want 0", len(got.Cosignatures))
+	}
+}
+
+func TestCosigningStateManagerMergesSameSizeCosignatures(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	a := NewMockWitnessClient(mockCtrl)
+	a.EXPECT().Add(gomock.Any(), gomock.Any()).Return(WitnessCosignature{WitnessKeyID: "a", Signature: []byte("sig-a")}, nil)
+	slowB := NewMockWitnessClient(mockCtrl)
+	slowB.EXPECT().Add(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, _ ct.SignedTreeHead) (WitnessCosignature, error) {
+		<-ctx.Done()
+		return WitnessCosignature{}, ctx.Err()
+	})
+
+	m := NewCosigningStateManager(map[string]WitnessClient{"a": a, "b": slowB}, time.Minute, 10*time.Millisecond)
+	m.cosign(context.Background(), sth(10))
+
+	got, err := m.GetSTHCosigned()
+	if err != nil {
+		t.Fatalf("GetSTHCosigned failed: %v", err)
+	}
+	if len(got.Cosignatures) != 1 {
+		t.Fatalf("got %d cosignatures, want 1 (only witness a responded)", len(got.Cosignatures))
+	}
+
+	// Same TreeSize, second round: b now responds. Its cosignature must
+	// be merged in alongside a's, not replace the whole set.
+	b := NewMockWitnessClient(mockCtrl)
+	b.EXPECT().Add(gomock.Any(), gomock.Any()).Return(WitnessCosignature{WitnessKeyID: "b", Signature: []byte("sig-b")}, nil)
+	timeoutA := NewMockWitnessClient(mockCtrl)
+	timeoutA.EXPECT().Add(gomock.Any(), gomock.Any()).DoAndReturn(func(ctx context.Context, _ ct.SignedTreeHead) (WitnessCosignature, error) {
+		<-ctx.Done()
+		return WitnessCosignature{}, ctx.Err()
+	})
+	m.witnesses = map[string]WitnessClient{"a": timeoutA, "b": b}
+	m.cosign(context.Background(), sth(10))
+
+	got, err = m.GetSTHCosigned()
+	if err != nil {
+		t.Fatalf("GetSTHCosigned failed: %v", err)
+	}
+	if got.STH.TreeSize != 10 {
+		t.Fatalf("got tree size %d, want 10", got.STH.TreeSize)
+	}
+	if len(got.Cosignatures) != 2 {
+		t.Fatalf("got %d cosignatures, want 2 (a's from round one merged with b's from round two)", len(got.Cosignatures))
+	}
+}
@@ -0,0 +1,48 @@
+package ct
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"time"
+
+	"github.com/golang/mock/gomock"
+)
+
+// fixedPublicKeyDER is the (fake) DER-encoded SubjectPublicKeyInfo that the
+// mock key manager hands out in these tests. ctMockLogID is derived from it.
+var fixedPublicKeyDER = []byte{
+	0x30, 0x59, 0x30, 0x13, 0x06, 0x07, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x02, 0x01,
+	0x06, 0x08, 0x2a, 0x86, 0x48, 0xce, 0x3d, 0x03, 0x01, 0x07, 0x03, 0x42, 0x00,
+	0x04, 0xec, 0x8a, 0x02, 0x32, 0xc6, 0x8f, 0x4e, 0x60, 0xcd, 0x74, 0x8f, 0x0b,
+	0x57, 0x50, 0x04, 0x23, 0xd5, 0xfd, 0x27, 0x9d, 0xf9, 0xea, 0xd7, 0x2e, 0xf3,
+	0xec, 0xf9, 0x40, 0x92, 0x24, 0x7e, 0xcb,
+}
+
+// ctMockLogID is the LogID produced from fixedPublicKeyDER; every test that
+// sets up a mock key manager via setupMockKeyManager expects to see this
+// value come back in the resulting SCT.
+var ctMockLogID = func() string {
+	h := sha256.Sum256(fixedPublicKeyDER)
+	return base64.StdEncoding.EncodeToString(h[:])
+}()
+
+// fixedTime is the fixed point in time (2017-09-07T12:15:23Z) used whenever
+// a test needs a deterministic SCT timestamp.
+var fixedTime = time.Date(2017, 9, 7, 12, 15, 23, 0, time.UTC)
+
+// setupMockKeyManager returns a KeyManager mock that expects to be asked to
+// sign exactly expectedDigest, and always reports fixedPublicKeyDER as its
+// public key.
+func setupMockKeyManager(ctrl *gomock.Controller, expectedDigest []byte) *MockKeyManager {
+	return setupMockKeyManagerWithAlgorithm(ctrl, expectedDigest, SignatureAlgorithmRSA)
+}
+
+// setupMockKeyManagerWithAlgorithm is setupMockKeyManager for a KeyManager
+// advertising a SignatureAlgorithm other than the default RSA.
+func setupMockKeyManagerWithAlgorithm(ctrl *gomock.Controller, expectedSignInput []byte, alg SignatureAlgorithm) *MockKeyManager {
+	km := NewMockKeyManager(ctrl)
+	km.EXPECT().Sign(gomock.Eq(expectedSignInput)).Return([]byte("signed"), nil)
+	km.EXPECT().GetRawPublicKey().AnyTimes().Return(fixedPublicKeyDER, nil)
+	km.EXPECT().SignatureAlgorithm().AnyTimes().Return(alg)
+	return km
+}
@@ -0,0 +1,42 @@
+package ct
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/google/certificate-transparency/go/x509"
+)
+
+// LogConfig describes the handler-visible configuration for a single CT
+// log instance: which key signs its entries, and which SCT version(s) it
+// emits.
+type LogConfig struct {
+	// Prefix is the log's request path prefix, e.g. "/ct/v1/".
+	Prefix string
+	// KeyManager signs this log's SCTs and STHs.
+	KeyManager KeyManager
+	// Version selects whether add-chain/add-pre-chain return a V1 SCT, a
+	// V2 SCT, or both.
+	Version EmitVersion
+}
+
+// addChainHandler implements the add-chain endpoint: it signs cert per the
+// log's configured Version and returns the resulting SCT(s).
+func addChainHandler(cfg *LogConfig, cert *x509.Certificate) (SignedEntry, error) {
+	if cfg.KeyManager == nil {
+		return SignedEntry{}, fmt.Errorf("ct: log %q has no configured key manager", cfg.Prefix)
+	}
+	return SignSCTForCertificate(cfg.KeyManager, cert, time.Now(), cfg.Version)
+}
+
+// addPreChainHandler implements the add-pre-chain endpoint: it signs the
+// precertificate chain[0] per the log's configured Version and returns the
+// resulting SCT(s). chain is the full submitted chain, needed to resolve
+// IssuerKeyHash correctly when chain[1] is an RFC 6962 section 3.1
+// pre-issuer.
+func addPreChainHandler(cfg *LogConfig, chain []*x509.Certificate) (SignedEntry, error) {
+	if cfg.KeyManager == nil {
+		return SignedEntry{}, fmt.Errorf("ct: log %q has no configured key manager", cfg.Prefix)
+	}
+	return SignSCTForPrecertificate(cfg.KeyManager, chain, time.Now(), cfg.Version)
+}
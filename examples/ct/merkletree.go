@@ -0,0 +1,75 @@
+package ct
+
+import "crypto/sha256"
+
+// rfc6962LeafHash and rfc6962NodeHash implement the domain-separated
+// hashing from RFC 6962 section 2.1: leaves are prefixed with 0x00,
+// internal nodes with 0x01, so an attacker can't pass off an internal node
+// as a leaf or vice versa.
+func rfc6962LeafHash(data []byte) [32]byte {
+	return sha256.Sum256(append([]byte{0x00}, data...))
+}
+
+func rfc6962NodeHash(left, right [32]byte) [32]byte {
+	buf := make([]byte, 0, 1+len(left)+len(right))
+	buf = append(buf, 0x01)
+	buf = append(buf, left[:]...)
+	buf = append(buf, right[:]...)
+	return sha256.Sum256(buf)
+}
+
+// merkleTree is the full set of levels of an in-memory RFC 6962 Merkle tree
+// over a fixed list of leaves, kept around so audit paths can be read back
+// out after the fact. levels[0] holds the leaf hashes; each subsequent
+// level holds the hashes one level up, ending in a single root hash.
+type merkleTree struct {
+	levels [][][32]byte
+}
+
+// buildMerkleTree hashes leaves and builds every level of the tree above
+// them.
+func buildMerkleTree(leaves [][]byte) *merkleTree {
+	level := make([][32]byte, len(leaves))
+	for i, l := range leaves {
+		level[i] = rfc6962LeafHash(l)
+	}
+
+	t := &merkleTree{levels: [][][32]byte{level}}
+	for len(level) > 1 {
+		next := make([][32]byte, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			if i+1 == len(level) {
+				// RFC 6962 carries an unpaired final node up a level
+				// unchanged rather than hashing it with itself.
+				next = append(next, level[i])
+				continue
+			}
+			next = append(next, rfc6962NodeHash(level[i], level[i+1]))
+		}
+		t.levels = append(t.levels, next)
+		level = next
+	}
+	return t
+}
+
+// root returns the tree's root hash. For a single-leaf tree, that is the
+// leaf hash itself.
+func (t *merkleTree) root() []byte {
+	top := t.levels[len(t.levels)-1]
+	r := top[0]
+	return r[:]
+}
+
+// auditPath returns the sibling hashes proving leaf index's inclusion,
+// ordered from the leaf's level up to the root.
+func (t *merkleTree) auditPath(index int) [][32]byte {
+	var path [][32]byte
+	for _, level := range t.levels[:len(t.levels)-1] {
+		sibling := index ^ 1
+		if sibling < len(level) {
+			path = append(path, level[sibling])
+		}
+		index /= 2
+	}
+	return path
+}
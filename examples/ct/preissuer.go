@@ -0,0 +1,198 @@
+package ct
+
+import (
+	"crypto/sha256"
+	"encoding/asn1"
+	"fmt"
+
+	"github.com/google/certificate-transparency/go/x509"
+)
+
+// ctPoisonOID is the OID of the RFC 6962 CT "poison" extension that marks a
+// TBSCertificate as a precertificate rather than something a client should
+// accept.
+var ctPoisonOID = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 3}
+
+// preIssuerEKU is the id-kp-PreCertificateSigning EKU (RFC 6962 section
+// 3.1) that identifies a dedicated CT pre-issuer: an intermediate whose
+// only job is signing precertificates on behalf of its parent CA.
+var preIssuerEKU = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 11129, 2, 4, 4}
+
+// akidOID is the id-ce-authorityKeyIdentifier extension (RFC 5280 section
+// 4.2.1.1).
+var akidOID = asn1.ObjectIdentifier{2, 5, 29, 35}
+
+// IsPreIssuer reports whether cert is a dedicated CT pre-issuer per RFC
+// 6962 section 3.1, i.e. it carries the id-kp-PreCertificateSigning EKU.
+func IsPreIssuer(cert *x509.Certificate) bool {
+	for _, eku := range cert.UnknownExtKeyUsage {
+		if eku.Equal(preIssuerEKU) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolvePrecertIssuer finds the certificate whose key hash should appear
+// in a precertificate's IssuerKeyHash, given the full chain the
+// precertificate was submitted with (chain[0] is the precertificate
+// itself). If chain[1] is an ordinary issuer, that's the answer. If
+// chain[1] is a dedicated CT pre-issuer (RFC 6962 section 3.1), the
+// pre-issuer's own parent -- chain[2] -- is used instead, since clients
+// never see the pre-issuer in the final certificate's chain.
+func resolvePrecertIssuer(chain []*x509.Certificate) (*x509.Certificate, error) {
+	if len(chain) < 2 {
+		return nil, fmt.Errorf("ct: precertificate chain has no issuer")
+	}
+	issuer := chain[1]
+	if !IsPreIssuer(issuer) {
+		return issuer, nil
+	}
+	if len(chain) < 3 {
+		return nil, fmt.Errorf("ct: precertificate chain ends at a pre-issuer with no parent")
+	}
+	return chain[2], nil
+}
+
+// tbsExtension is a RFC 5280 Extension, parsed just enough to let us find,
+// drop or replace extensions by OID; Value is kept as raw bytes.
+type tbsExtension struct {
+	Raw      asn1.RawContent
+	Id       asn1.ObjectIdentifier
+	Critical bool `asn1:"optional"`
+	Value    []byte
+}
+
+// tbsCertificate mirrors RFC 5280's TBSCertificate. Every field we don't
+// need to interpret is kept as asn1.RawValue so re-marshaling reproduces
+// its original encoding exactly; only Extensions is decoded structurally,
+// since that's the field signV1SCTForPrecertificate needs to edit.
+type tbsCertificate struct {
+	Raw                asn1.RawContent
+	Version            asn1.RawValue `asn1:"optional,explicit,tag:0"`
+	SerialNumber       asn1.RawValue
+	SignatureAlgorithm asn1.RawValue
+	Issuer             asn1.RawValue
+	Validity           asn1.RawValue
+	Subject            asn1.RawValue
+	PublicKey          asn1.RawValue
+	IssuerUniqueID     asn1.RawValue  `asn1:"optional,tag:1"`
+	SubjectUniqueID    asn1.RawValue  `asn1:"optional,tag:2"`
+	Extensions         []tbsExtension `asn1:"optional,explicit,tag:3"`
+}
+
+// authorityKeyIdentifier mirrors RFC 5280's AuthorityKeyIdentifier well
+// enough to read and write the keyIdentifier field, which is all Trillian's
+// pre-issuer rewriting needs.
+type authorityKeyIdentifier struct {
+	KeyIdentifier []byte `asn1:"optional,tag:0"`
+}
+
+// stripPoisonExtension returns tbs with the CT poison extension removed, as
+// RFC 6962 section 3.2 requires when a PreCert's TBSCertificate is embedded
+// in the signature input and Merkle leaf. If tbs carries no poison
+// extension, it is returned unchanged (as a copy).
+func stripPoisonExtension(tbs []byte) ([]byte, error) {
+	parsed, err := parseTBSCertificate(tbs)
+	if err != nil {
+		return nil, err
+	}
+
+	kept := parsed.Extensions[:0]
+	found := false
+	for _, ext := range parsed.Extensions {
+		if ext.Id.Equal(ctPoisonOID) {
+			found = true
+			continue
+		}
+		kept = append(kept, ext)
+	}
+	if !found {
+		out := make([]byte, len(tbs))
+		copy(out, tbs)
+		return out, nil
+	}
+	parsed.Extensions = kept
+	return marshalTBSCertificate(parsed)
+}
+
+// rewriteAKID returns tbs with its authorityKeyIdentifier extension's
+// keyIdentifier replaced by newKeyID (adding the extension if tbs has
+// none), as required when a precertificate's immediate issuer was a
+// dedicated CT pre-issuer: the final certificate's AKID -- and so the
+// log's reconstruction of it -- must point at the pre-issuer's parent, not
+// the pre-issuer itself.
+func rewriteAKID(tbs []byte, newKeyID []byte) ([]byte, error) {
+	parsed, err := parseTBSCertificate(tbs)
+	if err != nil {
+		return nil, err
+	}
+
+	value, err := asn1.Marshal(authorityKeyIdentifier{KeyIdentifier: newKeyID})
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal AuthorityKeyIdentifier: %v", err)
+	}
+
+	replaced := false
+	for i, ext := range parsed.Extensions {
+		if ext.Id.Equal(akidOID) {
+			parsed.Extensions[i].Value = value
+			parsed.Extensions[i].Raw = nil
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		parsed.Extensions = append(parsed.Extensions, tbsExtension{Id: akidOID, Value: value})
+	}
+	return marshalTBSCertificate(parsed)
+}
+
+func parseTBSCertificate(tbs []byte) (tbsCertificate, error) {
+	var parsed tbsCertificate
+	if _, err := asn1.Unmarshal(tbs, &parsed); err != nil {
+		return tbsCertificate{}, fmt.Errorf("failed to parse TBSCertificate: %v", err)
+	}
+	return parsed, nil
+}
+
+func marshalTBSCertificate(parsed tbsCertificate) ([]byte, error) {
+	parsed.Raw = nil
+	out, err := asn1.Marshal(parsed)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal TBSCertificate: %v", err)
+	}
+	return out, nil
+}
+
+// precertIssuerKeyHashAndTBS computes the IssuerKeyHash and poison-stripped,
+// AKID-corrected TBSCertificate that should be embedded in a
+// precertificate's MerkleTreeLeaf/SCT signature input, given the chain it
+// was submitted with.
+func precertIssuerKeyHashAndTBS(chain []*x509.Certificate) ([32]byte, []byte, error) {
+	if len(chain) == 0 {
+		return [32]byte{}, nil, fmt.Errorf("ct: empty precertificate chain")
+	}
+	precert := chain[0]
+	issuer, err := resolvePrecertIssuer(chain)
+	if err != nil {
+		return [32]byte{}, nil, err
+	}
+
+	tbs, err := stripPoisonExtension(precert.RawTBSCertificate)
+	if err != nil {
+		return [32]byte{}, nil, fmt.Errorf("failed to strip poison extension: %v", err)
+	}
+
+	if issuer != chain[1] {
+		// The immediate issuer was a dedicated pre-issuer: the TBS's AKID
+		// must name the pre-issuer's parent instead, matching the AKID
+		// the final, publicly-trusted certificate will carry.
+		tbs, err = rewriteAKID(tbs, issuer.SubjectKeyId)
+		if err != nil {
+			return [32]byte{}, nil, fmt.Errorf("failed to rewrite AKID for pre-issuer chain: %v", err)
+		}
+	}
+
+	return sha256.Sum256(issuer.RawSubjectPublicKeyInfo), tbs, nil
+}
@@ -0,0 +1,164 @@
+package ct
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+	ct "github.com/google/certificate-transparency/go"
+	"github.com/google/certificate-transparency/go/fixchain"
+	"github.com/google/certificate-transparency/go/x509"
+	"github.com/google/trillian/examples/ct/testonly"
+)
+
+// wantTBSPreIssuerHex is testonly.PrecertPEMValidPreIssuer's TBSCertificate,
+// hand-decoded from its DER and re-encoded with the poison extension
+// dropped and the authorityKeyIdentifier extension's key identifier
+// replaced by FakeIntermediateCertPem's (the pre-issuer's parent, not
+// PreIssuerCertPem's own key). Computed independently of this package's
+// ASN.1 helpers, as a golden vector for TestSignV1SCTForPrecertificatePreIssuer.
+const wantTBSPreIssuerHex = "3082018da0030201020214331547831f506935b4113f22905748dd06e066fb300a06082a8648ce3d040302303431153013060355040a0c0c46616b6520435420526f6f74311b301906035504030c1246616b65204354205072652d497373756572301e170d3236303732363134343331385a170d3238313032383134343331385a303631153013060355040a0c0c46616b65204354204c656166311d301b06035504030c1470726563657274322e6578616d706c652e636f6d3059301306072a8648ce3d020106082a8648ce3d03010703420004538e1bcdc6f1c6737403258c047c2cf82a4ffef0ec21af90a494c9e7ab2d2222ff51dd3d74ef39c897da5693c24e77f26be86e7552fb6f402a6264b060cc55b8a37b307930090603551d1304023000300b0603551d0f0404030205a0301f0603551d1104183016821470726563657274322e6578616d706c652e636f6d301d0603551d0e04160414f91098545f7a5e2ab2f89e97db894cecb0222cdb301f0603551d230418301680148f1a529164b4743a03216a59f0f958d724cf625b"
+
+func mustDecodeHex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatalf("failed to decode golden hex: %v", err)
+	}
+	return b
+}
+
+func TestIsPreIssuer(t *testing.T) {
+	intermediate, err := fixchain.CertificateFromPEM(testonly.FakeIntermediateCertPem)
+	if err != nil {
+		t.Fatalf("failed to set up test intermediate: %v", err)
+	}
+	if IsPreIssuer(intermediate) {
+		t.Fatal("IsPreIssuer(intermediate) = true, want false")
+	}
+
+	preIssuer, err := fixchain.CertificateFromPEM(testonly.PreIssuerCertPem)
+	if err != nil {
+		t.Fatalf("failed to set up test pre-issuer: %v", err)
+	}
+	if !IsPreIssuer(preIssuer) {
+		t.Fatal("IsPreIssuer(preIssuer) = false, want true")
+	}
+}
+
+func TestSignV1SCTForPrecertificateDirectIssuer(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	precert, err := fixchain.CertificateFromPEM(testonly.PrecertPEMValid)
+	if _, ok := err.(x509.NonFatalErrors); err != nil && !ok {
+		t.Fatalf("failed to set up test precert: %v", err)
+	}
+	issuer, err := fixchain.CertificateFromPEM(testonly.FakeIntermediateCertPem)
+	if err != nil {
+		t.Fatalf("failed to set up test issuer: %v", err)
+	}
+	chain := []*x509.Certificate{precert, issuer}
+
+	wantKeyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+	wantTBS, err := stripPoisonExtension(precert.RawTBSCertificate)
+	if err != nil {
+		t.Fatalf("stripPoisonExtension failed: %v", err)
+	}
+
+	input, err := serializeV1SCTSignatureInput(ct.PrecertLogEntryType, timeToMillis(fixedTime), nil, wantKeyHash, wantTBS, ct.CTExtensions{})
+	if err != nil {
+		t.Fatalf("failed to build expected signature input: %v", err)
+	}
+	digest := sha256.Sum256(input)
+	km := setupMockKeyManager(mockCtrl, digest[:])
+
+	leaf, _, err := signV1SCTForPrecertificate(km, chain, fixedTime)
+	if err != nil {
+		t.Fatalf("signV1SCTForPrecertificate failed: %v", err)
+	}
+
+	if got := leaf.TimestampedEntry.PrecertEntry.IssuerKeyHash; got != wantKeyHash {
+		t.Fatalf("IssuerKeyHash = %x, want %x", got, wantKeyHash)
+	}
+	if got := leaf.TimestampedEntry.PrecertEntry.TBSCertificate; !bytes.Equal(got, wantTBS) {
+		t.Fatalf("TBSCertificate = %x, want %x", got, wantTBS)
+	}
+}
+
+func TestSignV1SCTForPrecertificatePreIssuer(t *testing.T) {
+	mockCtrl := gomock.NewController(t)
+	defer mockCtrl.Finish()
+
+	precert, err := fixchain.CertificateFromPEM(testonly.PrecertPEMValidPreIssuer)
+	if _, ok := err.(x509.NonFatalErrors); err != nil && !ok {
+		t.Fatalf("failed to set up test precert: %v", err)
+	}
+	preIssuer, err := fixchain.CertificateFromPEM(testonly.PreIssuerCertPem)
+	if err != nil {
+		t.Fatalf("failed to set up test pre-issuer: %v", err)
+	}
+	issuer, err := fixchain.CertificateFromPEM(testonly.FakeIntermediateCertPem)
+	if err != nil {
+		t.Fatalf("failed to set up test issuer: %v", err)
+	}
+	chain := []*x509.Certificate{precert, preIssuer, issuer}
+
+	// The pre-issuer must not be the one whose key hash/AKID end up in the
+	// leaf: clients never see it in the final certificate's chain.
+	wantKeyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
+	notWantKeyHash := sha256.Sum256(preIssuer.RawSubjectPublicKeyInfo)
+	if wantKeyHash == notWantKeyHash {
+		t.Fatal("test fixtures have the same key, can't distinguish issuer from pre-issuer")
+	}
+
+	// wantTBS is precert.RawTBSCertificate with the poison extension
+	// removed and the authorityKeyIdentifier extension rewritten to name
+	// issuer (FakeIntermediateCertPem) rather than preIssuer, computed
+	// independently of stripPoisonExtension/rewriteAKID by hand-decoding
+	// the DER so a bug shared between the helpers and this test can't
+	// hide a wrong answer from both.
+	wantTBS := mustDecodeHex(t, wantTBSPreIssuerHex)
+
+	input, err := serializeV1SCTSignatureInput(ct.PrecertLogEntryType, timeToMillis(fixedTime), nil, wantKeyHash, wantTBS, ct.CTExtensions{})
+	if err != nil {
+		t.Fatalf("failed to build expected signature input: %v", err)
+	}
+	digest := sha256.Sum256(input)
+	km := setupMockKeyManager(mockCtrl, digest[:])
+
+	leaf, _, err := signV1SCTForPrecertificate(km, chain, fixedTime)
+	if err != nil {
+		t.Fatalf("signV1SCTForPrecertificate failed: %v", err)
+	}
+
+	if got := leaf.TimestampedEntry.PrecertEntry.IssuerKeyHash; got != wantKeyHash {
+		t.Fatalf("IssuerKeyHash = %x, want %x (the pre-issuer's parent, not the pre-issuer itself)", got, wantKeyHash)
+	}
+	if got := leaf.TimestampedEntry.PrecertEntry.TBSCertificate; !bytes.Equal(got, wantTBS) {
+		t.Fatalf("TBSCertificate = %x, want %x", got, wantTBS)
+	}
+	if got := leaf.TimestampedEntry.PrecertEntry.TBSCertificate; bytes.Equal(got, precert.RawTBSCertificate) {
+		t.Fatal("TBSCertificate was not rewritten for the pre-issuer chain")
+	}
+}
+
+func TestResolvePrecertIssuerErrors(t *testing.T) {
+	precert, err := fixchain.CertificateFromPEM(testonly.PrecertPEMValid)
+	if _, ok := err.(x509.NonFatalErrors); err != nil && !ok {
+		t.Fatalf("failed to set up test precert: %v", err)
+	}
+	preIssuer, err := fixchain.CertificateFromPEM(testonly.PreIssuerCertPem)
+	if err != nil {
+		t.Fatalf("failed to set up test pre-issuer: %v", err)
+	}
+
+	if _, err := resolvePrecertIssuer([]*x509.Certificate{precert}); err == nil {
+		t.Fatal("resolvePrecertIssuer succeeded on a chain with no issuer")
+	}
+	if _, err := resolvePrecertIssuer([]*x509.Certificate{precert, preIssuer}); err == nil {
+		t.Fatal("resolvePrecertIssuer succeeded on a chain ending in a pre-issuer with no parent")
+	}
+}
@@ -0,0 +1,211 @@
+package ct
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	ct "github.com/google/certificate-transparency/go"
+	"github.com/google/certificate-transparency/go/tls"
+	"github.com/google/certificate-transparency/go/x509"
+)
+
+// signatureType values from RFC 6962 section 3.2.
+const (
+	certificateTimestampSignatureType = 0
+)
+
+// SignatureAlgorithm identifies the signing algorithm a KeyManager uses, so
+// that DigitallySigned.Algorithm and the pre-signing hash step can be
+// chosen correctly instead of being hard-wired to RSA/SHA-256.
+type SignatureAlgorithm int
+
+const (
+	// SignatureAlgorithmRSA signs a SHA-256 digest with RSA (PKCS#1 v1.5).
+	SignatureAlgorithmRSA SignatureAlgorithm = iota
+	// SignatureAlgorithmECDSAP256 signs a SHA-256 digest with ECDSA over
+	// the P-256 curve.
+	SignatureAlgorithmECDSAP256
+	// SignatureAlgorithmEd25519 signs the message directly with Ed25519;
+	// Ed25519 hashes internally, so no separate pre-hash step is applied.
+	SignatureAlgorithmEd25519
+)
+
+// KeyManager is the signing interface required to produce SCTs. It is
+// deliberately narrow: callers plug in whatever backs their log's private
+// key (PEM file, PKCS#11 token, HSM, ...) behind this interface.
+type KeyManager interface {
+	// Sign returns a signature over data, using whatever key the manager
+	// holds. For SignatureAlgorithmEd25519, data is the message itself;
+	// for every other algorithm it is a SHA-256 digest of the message.
+	Sign(data []byte) ([]byte, error)
+	// GetRawPublicKey returns the DER encoding of the manager's public key
+	// (its SubjectPublicKeyInfo). The log's LogID is derived from this.
+	GetRawPublicKey() ([]byte, error)
+	// SignatureAlgorithm reports which algorithm Sign implements.
+	SignatureAlgorithm() SignatureAlgorithm
+}
+
+// timeToMillis converts a wall-clock time to the millisecond-since-epoch
+// timestamp used throughout the CT wire formats.
+func timeToMillis(t time.Time) uint64 {
+	return uint64(t.UnixNano() / 1e6)
+}
+
+// logIDFromKeyManager derives a log's SHA-256 LogID from its public key, as
+// required by RFC 6962 section 3.2.
+func logIDFromKeyManager(km KeyManager) (ct.SHA256Hash, error) {
+	pub, err := km.GetRawPublicKey()
+	if err != nil {
+		return ct.SHA256Hash{}, fmt.Errorf("failed to get log public key: %v", err)
+	}
+	return ct.SHA256Hash(sha256.Sum256(pub)), nil
+}
+
+// signDigitally asks km to sign input, hashing it first unless km signs
+// with Ed25519 (which hashes internally and must see the raw message), and
+// wraps the result as a DigitallySigned carrying the matching algorithm
+// pair.
+func signDigitally(km KeyManager, input []byte) (ct.DigitallySigned, error) {
+	alg := km.SignatureAlgorithm()
+
+	if alg == SignatureAlgorithmEd25519 {
+		sig, err := km.Sign(input)
+		if err != nil {
+			return ct.DigitallySigned{}, fmt.Errorf("failed to sign data: %v", err)
+		}
+		return ct.DigitallySigned{
+			Algorithm: tls.SignatureAndHashAlgorithm{
+				Hash:      tls.SHA256,
+				Signature: tls.Anonymous,
+			},
+			Signature: sig,
+		}, nil
+	}
+
+	sigAlg := tls.RSA
+	if alg == SignatureAlgorithmECDSAP256 {
+		sigAlg = tls.ECDSA
+	}
+
+	h := sha256.Sum256(input)
+	sig, err := km.Sign(h[:])
+	if err != nil {
+		return ct.DigitallySigned{}, fmt.Errorf("failed to sign data: %v", err)
+	}
+	return ct.DigitallySigned{
+		Algorithm: tls.SignatureAndHashAlgorithm{
+			Hash:      tls.SHA256,
+			Signature: sigAlg,
+		},
+		Signature: sig,
+	}, nil
+}
+
+// buildV1MerkleTreeLeaf assembles the V1 MerkleTreeLeaf for either a
+// certificate (entryType == ct.X509LogEntryType, issuerKeyHash/tbs unused)
+// or a precertificate (entryType == ct.PrecertLogEntryType, certDER unused).
+func buildV1MerkleTreeLeaf(entryType ct.LogEntryType, timestamp uint64, certDER []byte, issuerKeyHash [32]byte, tbs []byte) ct.MerkleTreeLeaf {
+	entry := &ct.TimestampedEntry{
+		Timestamp: timestamp,
+		EntryType: entryType,
+	}
+	switch entryType {
+	case ct.X509LogEntryType:
+		entry.X509Entry = &ct.ASN1Cert{Data: certDER}
+	case ct.PrecertLogEntryType:
+		entry.PrecertEntry = &ct.PreCert{
+			IssuerKeyHash:  issuerKeyHash,
+			TBSCertificate: tbs,
+		}
+	}
+
+	return ct.MerkleTreeLeaf{
+		Version:          ct.V1,
+		LeafType:         ct.TimestampedEntryLeafType,
+		TimestampedEntry: entry,
+	}
+}
+
+// serializeV1SCTSignatureInput builds the "signed-entry" structure described
+// by RFC 6962 section 3.2 that signV1SCTFor* signs over.
+func serializeV1SCTSignatureInput(entryType ct.LogEntryType, timestamp uint64, certDER []byte, issuerKeyHash [32]byte, tbs []byte, extensions ct.CTExtensions) ([]byte, error) {
+	var buf tlsBuffer
+	buf.writeUint8(uint8(ct.V1))
+	buf.writeUint8(certificateTimestampSignatureType)
+	buf.writeUint64(timestamp)
+	buf.writeUint16(uint16(entryType))
+
+	switch entryType {
+	case ct.X509LogEntryType:
+		if err := buf.writeUint24Opaque(certDER); err != nil {
+			return nil, err
+		}
+	case ct.PrecertLogEntryType:
+		buf.writeRaw(issuerKeyHash[:])
+		if err := buf.writeUint24Opaque(tbs); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("ct: unsupported entry type for SCT signing: %v", entryType)
+	}
+
+	if err := buf.writeUint16Opaque(extensions); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// signV1SCT does the common work shared by signV1SCTForCertificate and
+// signV1SCTForPrecertificate: build the MerkleTreeLeaf, serialize the
+// signature input and sign it.
+func signV1SCT(km KeyManager, entryType ct.LogEntryType, certDER []byte, issuerKeyHash [32]byte, tbs []byte, t time.Time) (ct.MerkleTreeLeaf, ct.SignedCertificateTimestamp, error) {
+	timestamp := timeToMillis(t)
+	leaf := buildV1MerkleTreeLeaf(entryType, timestamp, certDER, issuerKeyHash, tbs)
+
+	input, err := serializeV1SCTSignatureInput(entryType, timestamp, certDER, issuerKeyHash, tbs, ct.CTExtensions{})
+	if err != nil {
+		return ct.MerkleTreeLeaf{}, ct.SignedCertificateTimestamp{}, fmt.Errorf("failed to serialize signature input: %v", err)
+	}
+
+	sig, err := signDigitally(km, input)
+	if err != nil {
+		return ct.MerkleTreeLeaf{}, ct.SignedCertificateTimestamp{}, err
+	}
+
+	logID, err := logIDFromKeyManager(km)
+	if err != nil {
+		return ct.MerkleTreeLeaf{}, ct.SignedCertificateTimestamp{}, err
+	}
+
+	sct := ct.SignedCertificateTimestamp{
+		SCTVersion: ct.V1,
+		LogID:      ct.LogID{KeyID: logID},
+		Timestamp:  timestamp,
+		Extensions: ct.CTExtensions{},
+		Signature:  sig,
+	}
+	return leaf, sct, nil
+}
+
+// signV1SCTForCertificate builds and signs a V1 SCT for an ordinary
+// (non-precert) certificate.
+func signV1SCTForCertificate(km KeyManager, cert *x509.Certificate, t time.Time) (ct.MerkleTreeLeaf, ct.SignedCertificateTimestamp, error) {
+	return signV1SCT(km, ct.X509LogEntryType, cert.Raw, [32]byte{}, nil, t)
+}
+
+// signV1SCTForPrecertificate builds and signs a V1 SCT for a
+// precertificate. chain is the full submitted chain, chain[0] being the
+// precertificate itself: when chain[1] is an ordinary issuer its key hash
+// and the precert's own TBSCertificate (poison extension stripped) are
+// used directly, but when chain[1] is a dedicated CT pre-issuer (RFC 6962
+// section 3.1) the IssuerKeyHash and TBS AKID are resolved to the
+// pre-issuer's parent instead, matching what the final certificate will
+// carry.
+func signV1SCTForPrecertificate(km KeyManager, chain []*x509.Certificate, t time.Time) (ct.MerkleTreeLeaf, ct.SignedCertificateTimestamp, error) {
+	issuerKeyHash, tbs, err := precertIssuerKeyHashAndTBS(chain)
+	if err != nil {
+		return ct.MerkleTreeLeaf{}, ct.SignedCertificateTimestamp{}, err
+	}
+	return signV1SCT(km, ct.PrecertLogEntryType, nil, issuerKeyHash, tbs, t)
+}
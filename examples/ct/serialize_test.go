@@ -42,7 +42,7 @@ func TestSignV1SCTForCertificate(t *testing.T) {
 	expected := ct.SignedCertificateTimestamp{
 		SCTVersion: 0,
 		LogID:      ct.LogID{KeyID: ct.SHA256Hash(idArray)},
-		Timestamp:  1504786523000000,
+		Timestamp:  1504786523000,
 		Extensions: ct.CTExtensions{},
 		Signature: ct.DigitallySigned{
 			Algorithm: tls.SignatureAndHashAlgorithm{
@@ -84,10 +84,24 @@ func TestSignV1SCTForPrecertificate(t *testing.T) {
 	if err != nil && !ok {
 		t.Fatalf("failed to set up test precert: %v", err)
 	}
+	issuer, err := fixchain.CertificateFromPEM(testonly.FakeIntermediateCertPem)
+	if err != nil {
+		t.Fatalf("failed to set up test issuer: %v", err)
+	}
+	chain := []*x509.Certificate{cert, issuer}
 
-	km := setupMockKeyManager(mockCtrl, []byte{0x77, 0xf3, 0x5c, 0xc6, 0xad, 0x85, 0xfd, 0xe0, 0x38, 0xfd, 0x36, 0x34, 0x5c, 0x1e, 0x45, 0x58, 0x60, 0x95, 0xb1, 0x7c, 0x28, 0xaa, 0xa5, 0xa5, 0x84, 0x96, 0x37, 0x4b, 0xf8, 0xbb, 0xd9, 0x8})
+	wantTBS, err := stripPoisonExtension(cert.RawTBSCertificate)
+	if err != nil {
+		t.Fatalf("stripPoisonExtension failed: %v", err)
+	}
+	input, err := serializeV1SCTSignatureInput(ct.PrecertLogEntryType, timeToMillis(fixedTime), nil, sha256.Sum256(issuer.RawSubjectPublicKeyInfo), wantTBS, ct.CTExtensions{})
+	if err != nil {
+		t.Fatalf("failed to build expected signature input: %v", err)
+	}
+	digest := sha256.Sum256(input)
+	km := setupMockKeyManager(mockCtrl, digest[:])
 
-	leaf, got, err := signV1SCTForPrecertificate(km, cert, fixedTime)
+	leaf, got, err := signV1SCTForPrecertificate(km, chain, fixedTime)
 
 	if err != nil {
 		t.Fatalf("create sct for precert failed: %v", err)
@@ -104,7 +118,7 @@ func TestSignV1SCTForPrecertificate(t *testing.T) {
 
 	expected := ct.SignedCertificateTimestamp{SCTVersion: 0,
 		LogID:      ct.LogID{KeyID: ct.SHA256Hash(idArray)},
-		Timestamp:  1504786523000000,
+		Timestamp:  1504786523000,
 		Extensions: ct.CTExtensions{},
 		Signature: ct.DigitallySigned{
 			Algorithm: tls.SignatureAndHashAlgorithm{
@@ -117,7 +131,7 @@ func TestSignV1SCTForPrecertificate(t *testing.T) {
 	}
 
 	// Additional checks that the MerkleTreeLeaf we built is correct
-	keyHash := sha256.Sum256(cert.RawSubjectPublicKeyInfo)
+	keyHash := sha256.Sum256(issuer.RawSubjectPublicKeyInfo)
 
 	// Additional checks that the MerkleTreeLeaf we built is correct
 	if got, want := leaf.Version, ct.V1; got != want {
@@ -135,7 +149,7 @@ func TestSignV1SCTForPrecertificate(t *testing.T) {
 	if got, want := keyHash[:], leaf.TimestampedEntry.PrecertEntry.IssuerKeyHash[:]; !bytes.Equal(got, want) {
 		t.Fatalf("Issuer key hash bytes mismatch, got %v, expected %v", got, want)
 	}
-	if got, want := leaf.TimestampedEntry.PrecertEntry.TBSCertificate, cert.RawTBSCertificate; !bytes.Equal(got, want) {
+	if got, want := leaf.TimestampedEntry.PrecertEntry.TBSCertificate, wantTBS; !bytes.Equal(got, want) {
 		t.Fatalf("TBS cert mismatch, got %v, expected %v", got, want)
 	}
 }
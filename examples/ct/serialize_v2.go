@@ -0,0 +1,224 @@
+package ct
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"time"
+
+	ct "github.com/google/certificate-transparency/go"
+	"github.com/google/certificate-transparency/go/x509"
+)
+
+// sctVersionV2 is the wire value of the RFC 6962-bis V2 SCT/leaf version.
+// ct.V1 (0) is already defined by the upstream ct package; V2 (1) is not, so
+// it is declared locally.
+const sctVersionV2 ct.Version = 1
+
+// LogIDV2 identifies a V2 log by the ASN.1 OID of its public key's signature
+// algorithm together with a SHA-256 hash of the key itself, as described by
+// RFC 6962-bis section 4.8 (in place of V1's bare SHA-256(pubkey)).
+type LogIDV2 struct {
+	OID     []int
+	KeyHash [32]byte
+}
+
+// TimestampedEntryDataV2 is the V2 analogue of ct.TimestampedEntry: the
+// entry is not hashed separately from its extensions, so SCT extensions
+// live on the entry; signV2SCT also copies the same extensions onto the
+// SCT's own Extensions field.
+type TimestampedEntryDataV2 struct {
+	Timestamp    uint64
+	EntryType    ct.LogEntryType
+	X509Entry    *ct.ASN1Cert
+	PrecertEntry *ct.PreCert
+	Extensions   ct.CTExtensions
+}
+
+// MerkleTreeLeafV2 is the V2 analogue of ct.MerkleTreeLeaf.
+type MerkleTreeLeafV2 struct {
+	Version          ct.Version
+	TimestampedEntry *TimestampedEntryDataV2
+}
+
+// SignedCertificateTimestampDataV2 is the V2 analogue of
+// ct.SignedCertificateTimestamp.
+type SignedCertificateTimestampDataV2 struct {
+	SCTVersion ct.Version
+	LogID      LogIDV2
+	Timestamp  uint64
+	Extensions ct.CTExtensions
+	Signature  ct.DigitallySigned
+}
+
+// EmitVersion selects which SCT version(s) a log produces for a submission.
+type EmitVersion int
+
+const (
+	// EmitV1 emits only a V1 SCT, the long-standing RFC 6962 format.
+	EmitV1 EmitVersion = iota
+	// EmitV2 emits only a V2 (RFC 6962-bis) SCT.
+	EmitV2
+	// EmitDual emits both a V1 and a V2 SCT for the same submission.
+	EmitDual
+)
+
+// SignedEntry bundles whichever of the V1/V2 SCTs an EmitVersion calls for.
+type SignedEntry struct {
+	V1 *ct.SignedCertificateTimestamp
+	V2 *SignedCertificateTimestampDataV2
+}
+
+// logIDV2FromKeyManager derives a V2 LogID. The OID is fixed to the EC
+// public key algorithm for now; a KeyManager that advertises its own
+// algorithm (see SignatureAlgorithm) could supply a different OID once V2
+// support grows beyond ECDSA logs.
+func logIDV2FromKeyManager(km KeyManager) (LogIDV2, error) {
+	pub, err := km.GetRawPublicKey()
+	if err != nil {
+		return LogIDV2{}, fmt.Errorf("failed to get log public key: %v", err)
+	}
+	return LogIDV2{
+		OID:     []int{1, 2, 840, 10045, 2, 1}, // id-ecPublicKey
+		KeyHash: sha256.Sum256(pub),
+	}, nil
+}
+
+// serializeV2SCTSignatureInput builds the V2 signature input. As in V1, the
+// uint16-opaque extensions vector trails the entry-specific data -- it is
+// the last field of TimestampedEntryDataV2, not a prefix to it.
+func serializeV2SCTSignatureInput(entryType ct.LogEntryType, timestamp uint64, certDER []byte, issuerKeyHash [32]byte, tbs []byte, extensions ct.CTExtensions) ([]byte, error) {
+	var buf tlsBuffer
+	buf.writeUint8(uint8(sctVersionV2))
+	buf.writeUint8(certificateTimestampSignatureType)
+	buf.writeUint64(timestamp)
+	buf.writeUint16(uint16(entryType))
+
+	switch entryType {
+	case ct.X509LogEntryType:
+		if err := buf.writeUint24Opaque(certDER); err != nil {
+			return nil, err
+		}
+	case ct.PrecertLogEntryType:
+		buf.writeRaw(issuerKeyHash[:])
+		if err := buf.writeUint24Opaque(tbs); err != nil {
+			return nil, err
+		}
+	default:
+		return nil, fmt.Errorf("ct: unsupported entry type for SCT signing: %v", entryType)
+	}
+
+	if err := buf.writeUint16Opaque(extensions); err != nil {
+		return nil, err
+	}
+
+	return buf.Bytes(), nil
+}
+
+func signV2SCT(km KeyManager, entryType ct.LogEntryType, certDER []byte, issuerKeyHash [32]byte, tbs []byte, extensions ct.CTExtensions, t time.Time) (MerkleTreeLeafV2, SignedCertificateTimestampDataV2, error) {
+	timestamp := timeToMillis(t)
+
+	leaf := MerkleTreeLeafV2{
+		Version: sctVersionV2,
+		TimestampedEntry: &TimestampedEntryDataV2{
+			Timestamp:  timestamp,
+			EntryType:  entryType,
+			Extensions: extensions,
+		},
+	}
+	switch entryType {
+	case ct.X509LogEntryType:
+		leaf.TimestampedEntry.X509Entry = &ct.ASN1Cert{Data: certDER}
+	case ct.PrecertLogEntryType:
+		leaf.TimestampedEntry.PrecertEntry = &ct.PreCert{
+			IssuerKeyHash:  issuerKeyHash,
+			TBSCertificate: tbs,
+		}
+	}
+
+	input, err := serializeV2SCTSignatureInput(entryType, timestamp, certDER, issuerKeyHash, tbs, extensions)
+	if err != nil {
+		return MerkleTreeLeafV2{}, SignedCertificateTimestampDataV2{}, fmt.Errorf("failed to serialize V2 signature input: %v", err)
+	}
+
+	sig, err := signDigitally(km, input)
+	if err != nil {
+		return MerkleTreeLeafV2{}, SignedCertificateTimestampDataV2{}, err
+	}
+
+	logID, err := logIDV2FromKeyManager(km)
+	if err != nil {
+		return MerkleTreeLeafV2{}, SignedCertificateTimestampDataV2{}, err
+	}
+
+	sct := SignedCertificateTimestampDataV2{
+		SCTVersion: sctVersionV2,
+		LogID:      logID,
+		Timestamp:  timestamp,
+		Extensions: extensions,
+		Signature:  sig,
+	}
+	return leaf, sct, nil
+}
+
+// signV2SCTForCertificate builds and signs a V2 SCT for an ordinary
+// (non-precert) certificate.
+func signV2SCTForCertificate(km KeyManager, cert *x509.Certificate, t time.Time) (MerkleTreeLeafV2, SignedCertificateTimestampDataV2, error) {
+	return signV2SCT(km, ct.X509LogEntryType, cert.Raw, [32]byte{}, nil, ct.CTExtensions{}, t)
+}
+
+// signV2SCTForPrecertificate builds and signs a V2 SCT for a
+// precertificate. See signV1SCTForPrecertificate for how chain's
+// IssuerKeyHash and TBSCertificate are resolved, including the RFC 6962
+// section 3.1 pre-issuer case.
+func signV2SCTForPrecertificate(km KeyManager, chain []*x509.Certificate, t time.Time) (MerkleTreeLeafV2, SignedCertificateTimestampDataV2, error) {
+	issuerKeyHash, tbs, err := precertIssuerKeyHashAndTBS(chain)
+	if err != nil {
+		return MerkleTreeLeafV2{}, SignedCertificateTimestampDataV2{}, err
+	}
+	return signV2SCT(km, ct.PrecertLogEntryType, nil, issuerKeyHash, tbs, ct.CTExtensions{}, t)
+}
+
+// SignSCTForCertificate is the request-handler entry point for add-chain: it
+// signs cert per the log's configured EmitVersion and returns whichever of
+// the V1/V2 SCTs that version calls for.
+func SignSCTForCertificate(km KeyManager, cert *x509.Certificate, t time.Time, version EmitVersion) (SignedEntry, error) {
+	var out SignedEntry
+	if version == EmitV1 || version == EmitDual {
+		_, sct, err := signV1SCTForCertificate(km, cert, t)
+		if err != nil {
+			return SignedEntry{}, err
+		}
+		out.V1 = &sct
+	}
+	if version == EmitV2 || version == EmitDual {
+		_, sct, err := signV2SCTForCertificate(km, cert, t)
+		if err != nil {
+			return SignedEntry{}, err
+		}
+		out.V2 = &sct
+	}
+	return out, nil
+}
+
+// SignSCTForPrecertificate is the request-handler entry point for
+// add-pre-chain; see SignSCTForCertificate. chain is the full submitted
+// chain, chain[0] being the precertificate itself (see
+// signV1SCTForPrecertificate for how a pre-issuer chain is resolved).
+func SignSCTForPrecertificate(km KeyManager, chain []*x509.Certificate, t time.Time, version EmitVersion) (SignedEntry, error) {
+	var out SignedEntry
+	if version == EmitV1 || version == EmitDual {
+		_, sct, err := signV1SCTForPrecertificate(km, chain, t)
+		if err != nil {
+			return SignedEntry{}, err
+		}
+		out.V1 = &sct
+	}
+	if version == EmitV2 || version == EmitDual {
+		_, sct, err := signV2SCTForPrecertificate(km, chain, t)
+		if err != nil {
+			return SignedEntry{}, err
+		}
+		out.V2 = &sct
+	}
+	return out, nil
+}
@@ -0,0 +1,101 @@
+// Package testonly provides test-only fixtures (PEM certificate chains) used
+// by the examples/ct signing and verification tests. None of these keys or
+// certificates are suitable for anything other than unit tests.
+package testonly
+
+// FakeCACertPem is the self-signed root that issues FakeIntermediateCertPem.
+const FakeCACertPem = `-----BEGIN CERTIFICATE-----
+MIIBtzCCAV2gAwIBAgIUBqOgJt+CDvdIpoYoDY+MI/qSaoMwCgYIKoZIzj0EAwIw
+MTEVMBMGA1UECgwMRmFrZSBDVCBSb290MRgwFgYDVQQDDA9GYWtlIENUIFJvb3Qg
+Q0EwHhcNMjYwNzI2MTQ0MzA5WhcNMzYwNzIzMTQ0MzA5WjAxMRUwEwYDVQQKDAxG
+YWtlIENUIFJvb3QxGDAWBgNVBAMMD0Zha2UgQ1QgUm9vdCBDQTBZMBMGByqGSM49
+AgEGCCqGSM49AwEHA0IABFxBbH7wLA9tDOFDk771TbmkgrP1jBMLpeFc3K18augq
+pvv4EFAzeek4vU08AgzOdcfVf7JouaCEndj2O9M+686jUzBRMB0GA1UdDgQWBBR5
+1Wp7sIhHOt6/6Ql2aTu/YPK94zAfBgNVHSMEGDAWgBR51Wp7sIhHOt6/6Ql2aTu/
+YPK94zAPBgNVHRMBAf8EBTADAQH/MAoGCCqGSM49BAMCA0gAMEUCIQCOv61jWeYr
+pAHU5z1ZXTJSxotSXpXV60KDVoMxh88DBgIgalHiJyeVCOL+2BB+MnX1lcskd74F
+YisA4waMwWYocGc=
+-----END CERTIFICATE-----`
+
+// FakeIntermediateCertPem is a CA certificate signed by FakeCACertPem. It
+// plays the role of the "immediate issuer" in the signing tests.
+const FakeIntermediateCertPem = `-----BEGIN CERTIFICATE-----
+MIIBzjCCAXWgAwIBAgIUKQ/Z5Syqq1hMUTBcwBLyePbWslYwCgYIKoZIzj0EAwIw
+MTEVMBMGA1UECgwMRmFrZSBDVCBSb290MRgwFgYDVQQDDA9GYWtlIENUIFJvb3Qg
+Q0EwHhcNMjYwNzI2MTQ0MzA5WhcNMzEwNzI1MTQ0MzA5WjA2MRUwEwYDVQQKDAxG
+YWtlIENUIFJvb3QxHTAbBgNVBAMMFEZha2UgSW50ZXJtZWRpYXRlIENBMFkwEwYH
+KoZIzj0CAQYIKoZIzj0DAQcDQgAEh6xiUjDUjH1FGZ5Wybjq+GaklU0SzvLwEDaX
+Cweo2WTN1LYoIsG5q1Do1hOjixhDVV1VEryh0CIXG2tMNS/MZqNmMGQwEgYDVR0T
+AQH/BAgwBgEB/wIBADAOBgNVHQ8BAf8EBAMCAQYwHQYDVR0OBBYEFI8aUpFktHQ6
+AyFqWfD5WNckz2JbMB8GA1UdIwQYMBaAFHnVanuwiEc63r/pCXZpO79g8r3jMAoG
+CCqGSM49BAMCA0cAMEQCIG4nunY4xRmLhT3wJWhxjTEBE6x+4T8N00QInUAeWDQO
+AiBNeQynO2nI3tLCmo3CyMtMlyOnsBRkBRwNZougK0AtzA==
+-----END CERTIFICATE-----`
+
+// LeafSignedByFakeIntermediateCertPem is an ordinary leaf cert chaining
+// directly to FakeIntermediateCertPem.
+const LeafSignedByFakeIntermediateCertPem = `-----BEGIN CERTIFICATE-----
+MIIB+TCCAZ6gAwIBAgIUAbeUw8VNSjOf3nqXJjkuy4My1EgwCgYIKoZIzj0EAwIw
+NjEVMBMGA1UECgwMRmFrZSBDVCBSb290MR0wGwYDVQQDDBRGYWtlIEludGVybWVk
+aWF0ZSBDQTAeFw0yNjA3MjYxNDQzMDlaFw0yODEwMjgxNDQzMDlaMDIxFTATBgNV
+BAoMDEZha2UgQ1QgTGVhZjEZMBcGA1UEAwwQbGVhZi5leGFtcGxlLmNvbTBZMBMG
+ByqGSM49AgEGCCqGSM49AwEHA0IABO2KAjLGj05gzXSPC1dQBCPV/Sed+erXLvPs
++UCSJH7LUKJhaC4f/u4sKW1mcDLgKKadMB6lDfmrWxo0pupM5N2jgY0wgYowCQYD
+VR0TBAIwADALBgNVHQ8EBAMCBaAwEwYDVR0lBAwwCgYIKwYBBQUHAwEwGwYDVR0R
+BBQwEoIQbGVhZi5leGFtcGxlLmNvbTAdBgNVHQ4EFgQUODoPr5JbCPSrr0Io3ZV+
+0XJnRWUwHwYDVR0jBBgwFoAUjxpSkWS0dDoDIWpZ8PlY1yTPYlswCgYIKoZIzj0E
+AwIDSQAwRgIhALEeUxS/zOOQhISua2CAF8fin06tPwQV6eIqax5hlQnnAiEAql1q
+TdieNIm6swj59aVSQ+vsxtJTy2ebRt7xOYuVIgg=
+-----END CERTIFICATE-----`
+
+// PrecertPEMValid is a precertificate (carrying the CT poison extension)
+// issued directly by FakeIntermediateCertPem.
+const PrecertPEMValid = `-----BEGIN CERTIFICATE-----
+MIIB/TCCAaSgAwIBAgIUAbeUw8VNSjOf3nqXJjkuy4My1EkwCgYIKoZIzj0EAwIw
+NjEVMBMGA1UECgwMRmFrZSBDVCBSb290MR0wGwYDVQQDDBRGYWtlIEludGVybWVk
+aWF0ZSBDQTAeFw0yNjA3MjYxNDQzMThaFw0yODEwMjgxNDQzMThaMDUxFTATBgNV
+BAoMDEZha2UgQ1QgTGVhZjEcMBoGA1UEAwwTcHJlY2VydC5leGFtcGxlLmNvbTBZ
+MBMGByqGSM49AgEGCCqGSM49AwEHA0IABE0J1ChVp17oKG/273OmahEyFgdaLwkR
+3uS2HPY+C8lDb19ZDWY0z1H+wW2uLB4hvQZ9MtQm3iSDLCAH8W/eFcCjgZAwgY0w
+CQYDVR0TBAIwADALBgNVHQ8EBAMCBaAwHgYDVR0RBBcwFYITcHJlY2VydC5leGFt
+cGxlLmNvbTATBgorBgEEAdZ5AgQDAQH/BAIFADAdBgNVHQ4EFgQUwymGyA5ohExa
+Wo2WLXTui3vsgfowHwYDVR0jBBgwFoAUjxpSkWS0dDoDIWpZ8PlY1yTPYlswCgYI
+KoZIzj0EAwIDRwAwRAIgdH8M5hKgqvtAOioSOS4SVgIAfN4odrJe0Y1j94UwXUwC
+IFgSyXvCk7KxQdIxmM06I/62vWRhq2bodXG+FVKNCSgR
+-----END CERTIFICATE-----`
+
+// PreIssuerCertPem is a dedicated CT pre-issuer (RFC 6962 section 3.1,
+// identified by the id-kp-PreCertificateSigning EKU) chaining to
+// FakeIntermediateCertPem.
+const PreIssuerCertPem = `-----BEGIN CERTIFICATE-----
+MIIB6jCCAY+gAwIBAgIUAbeUw8VNSjOf3nqXJjkuy4My1EowCgYIKoZIzj0EAwIw
+NjEVMBMGA1UECgwMRmFrZSBDVCBSb290MR0wGwYDVQQDDBRGYWtlIEludGVybWVk
+aWF0ZSBDQTAeFw0yNjA3MjYxNDQzMThaFw0zMTA3MjUxNDQzMThaMDQxFTATBgNV
+BAoMDEZha2UgQ1QgUm9vdDEbMBkGA1UEAwwSRmFrZSBDVCBQcmUtSXNzdWVyMFkw
+EwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEOnieSLlvUAf3neR2NCsQ9yfOjjCtgn7H
+GtctF6qnSjtwcTo2kgEB80k046IhJzD0DRMDxFAB4P5PdG5y1XJZFqN9MHswEgYD
+VR0TAQH/BAgwBgEB/wIBADAOBgNVHQ8BAf8EBAMCAQYwFQYDVR0lBA4wDAYKKwYB
+BAHWeQIEBDAdBgNVHQ4EFgQUbig28eEWAZLL/YJmqQpWrLSJ0jswHwYDVR0jBBgw
+FoAUjxpSkWS0dDoDIWpZ8PlY1yTPYlswCgYIKoZIzj0EAwIDSQAwRgIhANNs3vF2
+bGhkdw/Jmx4B7rY0EOS6MAIc/kbaHymNrfFdAiEA3OFqQCDviAGytuFkuzIxn0sk
+YB3bCDsVQ6gHVeMuVpY=
+-----END CERTIFICATE-----`
+
+// PrecertPEMValidPreIssuer is a precertificate issued by PreIssuerCertPem
+// rather than directly by FakeIntermediateCertPem. Signers and verifiers must
+// resolve IssuerKeyHash and the TBS AKID to FakeIntermediateCertPem (the
+// pre-issuer's parent), and must strip the pre-issuer from the submitted
+// chain before hashing.
+const PrecertPEMValidPreIssuer = `-----BEGIN CERTIFICATE-----
+MIIB/jCCAaSgAwIBAgIUMxVHgx9QaTW0ET8ikFdI3QbgZvswCgYIKoZIzj0EAwIw
+NDEVMBMGA1UECgwMRmFrZSBDVCBSb290MRswGQYDVQQDDBJGYWtlIENUIFByZS1J
+c3N1ZXIwHhcNMjYwNzI2MTQ0MzE4WhcNMjgxMDI4MTQ0MzE4WjA2MRUwEwYDVQQK
+DAxGYWtlIENUIExlYWYxHTAbBgNVBAMMFHByZWNlcnQyLmV4YW1wbGUuY29tMFkw
+EwYHKoZIzj0CAQYIKoZIzj0DAQcDQgAEU44bzcbxxnN0AyWMBHws+CpP/vDsIa+Q
+pJTJ56stIiL/Ud09dO85yJfaVpPCTnfya+hudVL7b0AqYmSwYMxVuKOBkTCBjjAJ
+BgNVHRMEAjAAMAsGA1UdDwQEAwIFoDAfBgNVHREEGDAWghRwcmVjZXJ0Mi5leGFt
+cGxlLmNvbTATBgorBgEEAdZ5AgQDAQH/BAIFADAdBgNVHQ4EFgQU+RCYVF96Xiqy
++J6X24lM7LAiLNswHwYDVR0jBBgwFoAUbig28eEWAZLL/YJmqQpWrLSJ0jswCgYI
+KoZIzj0EAwIDSAAwRQIhAOgHcaATk+imsAAbk5y7+kVn+FBORIcdOg3WwsV2Y1rm
+AiAd7qGGovnU2VIjXoaseoDONIf4tyvMxWuTaf+9h6gnCQ==
+-----END CERTIFICATE-----`
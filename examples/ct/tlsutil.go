@@ -0,0 +1,60 @@
+package ct
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+)
+
+// tlsBuffer accumulates the big-endian, length-prefixed encoding used by the
+// CT wire formats (RFC 6962 section 3, renders TLS presentation language
+// "opaque" vectors).
+type tlsBuffer struct {
+	bytes.Buffer
+}
+
+func (b *tlsBuffer) writeUint8(v uint8) {
+	b.WriteByte(v)
+}
+
+func (b *tlsBuffer) writeUint16(v uint16) {
+	var tmp [2]byte
+	binary.BigEndian.PutUint16(tmp[:], v)
+	b.Write(tmp[:])
+}
+
+func (b *tlsBuffer) writeUint64(v uint64) {
+	var tmp [8]byte
+	binary.BigEndian.PutUint64(tmp[:], v)
+	b.Write(tmp[:])
+}
+
+func (b *tlsBuffer) writeRaw(v []byte) {
+	b.Write(v)
+}
+
+// writeUint24Opaque writes a length-prefixed vector whose length fits in 24
+// bits, i.e. opaque<0..2^24-1> in TLS presentation language.
+func (b *tlsBuffer) writeUint24Opaque(v []byte) error {
+	if len(v) >= 1<<24 {
+		return fmt.Errorf("ct: value too large for a uint24-prefixed vector: %d bytes", len(v))
+	}
+	var tmp [3]byte
+	tmp[0] = byte(len(v) >> 16)
+	tmp[1] = byte(len(v) >> 8)
+	tmp[2] = byte(len(v))
+	b.Write(tmp[:])
+	b.Write(v)
+	return nil
+}
+
+// writeUint16Opaque writes a length-prefixed vector whose length fits in 16
+// bits, i.e. opaque<0..2^16-1> in TLS presentation language.
+func (b *tlsBuffer) writeUint16Opaque(v []byte) error {
+	if len(v) >= 1<<16 {
+		return fmt.Errorf("ct: value too large for a uint16-prefixed vector: %d bytes", len(v))
+	}
+	b.writeUint16(uint16(len(v)))
+	b.Write(v)
+	return nil
+}
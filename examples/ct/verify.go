@@ -0,0 +1,174 @@
+package ct
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	cryptox509 "crypto/x509"
+	"fmt"
+
+	ct "github.com/google/certificate-transparency/go"
+	"github.com/google/certificate-transparency/go/tls"
+	"github.com/google/certificate-transparency/go/x509"
+)
+
+// LogVerifier checks SCTs and MerkleTreeLeaf entries issued by a single CT
+// log, using the log's public key to verify signatures and the log's LogID
+// to confirm an SCT claims to be from this log at all. It is the read-side
+// counterpart of KeyManager: submitters and monitors use it to check what a
+// log handed back, the same way signV1SCTFor* uses a KeyManager to produce
+// it.
+type LogVerifier struct {
+	pubKey crypto.PublicKey
+	logID  ct.SHA256Hash
+}
+
+// NewLogVerifier builds a LogVerifier for a log with the given public key.
+// pubKey must be an *rsa.PublicKey, *ecdsa.PublicKey or ed25519.PublicKey.
+func NewLogVerifier(pubKey crypto.PublicKey) (*LogVerifier, error) {
+	rawPub, err := cryptox509.MarshalPKIXPublicKey(pubKey)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal log public key: %v", err)
+	}
+	return &LogVerifier{
+		pubKey: pubKey,
+		logID:  ct.SHA256Hash(sha256.Sum256(rawPub)),
+	}, nil
+}
+
+// verifyDigitallySigned checks that ds is a valid signature by v's log over
+// input, using whichever crypto verifier matches ds.Algorithm.
+func (v *LogVerifier) verifyDigitallySigned(input []byte, ds ct.DigitallySigned) error {
+	switch ds.Algorithm.Signature {
+	case tls.RSA:
+		pub, ok := v.pubKey.(*rsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("ct: log key is %T, not RSA as the signature claims", v.pubKey)
+		}
+		h := sha256.Sum256(input)
+		return rsa.VerifyPKCS1v15(pub, crypto.SHA256, h[:], ds.Signature)
+	case tls.ECDSA:
+		pub, ok := v.pubKey.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("ct: log key is %T, not ECDSA as the signature claims", v.pubKey)
+		}
+		h := sha256.Sum256(input)
+		if !ecdsa.VerifyASN1(pub, h[:], ds.Signature) {
+			return fmt.Errorf("ct: ECDSA signature verification failed")
+		}
+		return nil
+	case tls.Anonymous:
+		pub, ok := v.pubKey.(ed25519.PublicKey)
+		if !ok {
+			return fmt.Errorf("ct: log key is %T, not Ed25519 as the signature claims", v.pubKey)
+		}
+		if !ed25519.Verify(pub, input, ds.Signature) {
+			return fmt.Errorf("ct: Ed25519 signature verification failed")
+		}
+		return nil
+	default:
+		return fmt.Errorf("ct: unsupported signature algorithm %v", ds.Algorithm.Signature)
+	}
+}
+
+// checkLogID returns an error if logID is not the LogID of v's log.
+func (v *LogVerifier) checkLogID(logID ct.LogID) error {
+	if logID.KeyID != v.logID {
+		return fmt.Errorf("ct: SCT LogID %x does not match verifier's log %x", logID.KeyID, v.logID)
+	}
+	return nil
+}
+
+// VerifyV1SCTForCertificate checks that sct is a valid V1 SCT, issued by
+// v's log, for cert. This does not apply to batch-mode SCTs from
+// BatchSigner, whose Signature is over a Merkle root rather than cert's own
+// signature input; see BatchSigner's doc comment.
+func (v *LogVerifier) VerifyV1SCTForCertificate(cert *x509.Certificate, sct ct.SignedCertificateTimestamp) error {
+	if sct.SCTVersion != ct.V1 {
+		return fmt.Errorf("ct: SCT has version %v, expected V1", sct.SCTVersion)
+	}
+	if err := v.checkLogID(sct.LogID); err != nil {
+		return err
+	}
+	input, err := serializeV1SCTSignatureInput(ct.X509LogEntryType, sct.Timestamp, cert.Raw, [32]byte{}, nil, sct.Extensions)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild signature input: %v", err)
+	}
+	return v.verifyDigitallySigned(input, sct.Signature)
+}
+
+// VerifyV1SCTForPrecertificate checks that sct is a valid V1 SCT, issued by
+// v's log, for the precertificate chain[0]. chain is the full submitted
+// chain, needed to resolve IssuerKeyHash correctly when chain[1] is an RFC
+// 6962 section 3.1 pre-issuer (see signV1SCTForPrecertificate).
+func (v *LogVerifier) VerifyV1SCTForPrecertificate(chain []*x509.Certificate, sct ct.SignedCertificateTimestamp) error {
+	if sct.SCTVersion != ct.V1 {
+		return fmt.Errorf("ct: SCT has version %v, expected V1", sct.SCTVersion)
+	}
+	if err := v.checkLogID(sct.LogID); err != nil {
+		return err
+	}
+	issuerKeyHash, tbs, err := precertIssuerKeyHashAndTBS(chain)
+	if err != nil {
+		return err
+	}
+	input, err := serializeV1SCTSignatureInput(ct.PrecertLogEntryType, sct.Timestamp, nil, issuerKeyHash, tbs, sct.Extensions)
+	if err != nil {
+		return fmt.Errorf("failed to rebuild signature input: %v", err)
+	}
+	return v.verifyDigitallySigned(input, sct.Signature)
+}
+
+// VerifyMerkleTreeLeaf checks that leaf is the MerkleTreeLeaf a log would
+// build for the certificate chain[0]: for an ordinary certificate, that
+// the embedded X509Entry bytes match chain[0].Raw; for a precertificate,
+// that the embedded PrecertEntry's IssuerKeyHash and TBSCertificate match
+// what signV1SCTForPrecertificate would have produced for chain, including
+// the RFC 6962 section 3.1 pre-issuer case.
+func VerifyMerkleTreeLeaf(leaf ct.MerkleTreeLeaf, chain []*x509.Certificate) error {
+	if leaf.Version != ct.V1 {
+		return fmt.Errorf("ct: MerkleTreeLeaf has version %v, expected V1", leaf.Version)
+	}
+	if leaf.LeafType != ct.TimestampedEntryLeafType {
+		return fmt.Errorf("ct: MerkleTreeLeaf has leaf type %v, expected TimestampedEntryLeafType", leaf.LeafType)
+	}
+	if leaf.TimestampedEntry == nil {
+		return fmt.Errorf("ct: MerkleTreeLeaf has no TimestampedEntry")
+	}
+	if len(chain) == 0 {
+		return fmt.Errorf("ct: empty certificate chain")
+	}
+	entry := leaf.TimestampedEntry
+	cert := chain[0]
+
+	switch entry.EntryType {
+	case ct.X509LogEntryType:
+		if entry.X509Entry == nil {
+			return fmt.Errorf("ct: MerkleTreeLeaf has no X509Entry")
+		}
+		if !bytes.Equal(entry.X509Entry.Data, cert.Raw) {
+			return fmt.Errorf("ct: MerkleTreeLeaf X509Entry does not match submitted certificate")
+		}
+		return nil
+	case ct.PrecertLogEntryType:
+		if entry.PrecertEntry == nil {
+			return fmt.Errorf("ct: MerkleTreeLeaf has no PrecertEntry")
+		}
+		wantKeyHash, wantTBS, err := precertIssuerKeyHashAndTBS(chain)
+		if err != nil {
+			return err
+		}
+		if entry.PrecertEntry.IssuerKeyHash != wantKeyHash {
+			return fmt.Errorf("ct: MerkleTreeLeaf IssuerKeyHash does not match submitted chain")
+		}
+		if !bytes.Equal(entry.PrecertEntry.TBSCertificate, wantTBS) {
+			return fmt.Errorf("ct: MerkleTreeLeaf TBSCertificate does not match submitted precertificate")
+		}
+		return nil
+	default:
+		return fmt.Errorf("ct: MerkleTreeLeaf has unsupported entry type %v", entry.EntryType)
+	}
+}
@@ -0,0 +1,108 @@
+package ct
+
+import (
+	"crypto/ecdsa"
+	"crypto/rand"
+	"testing"
+
+	"github.com/google/certificate-transparency/go/fixchain"
+	"github.com/google/certificate-transparency/go/x509"
+	"github.com/google/trillian/examples/ct/testonly"
+)
+
+func TestVerifyV1SCTForCertificate(t *testing.T) {
+	cert, err := fixchain.CertificateFromPEM(testonly.LeafSignedByFakeIntermediateCertPem)
+	if err != nil {
+		t.Fatalf("failed to set up test cert: %v", err)
+	}
+
+	km := newRealECDSAKeyManager(t)
+	_, sct, err := signV1SCTForCertificate(km, cert, fixedTime)
+	if err != nil {
+		t.Fatalf("signV1SCTForCertificate failed: %v", err)
+	}
+
+	v, err := NewLogVerifier(km.pub)
+	if err != nil {
+		t.Fatalf("NewLogVerifier failed: %v", err)
+	}
+	if err := v.VerifyV1SCTForCertificate(cert, sct); err != nil {
+		t.Fatalf("VerifyV1SCTForCertificate failed on a genuine SCT: %v", err)
+	}
+
+	// A signature from a different key must not verify.
+	other, err := ecdsa.GenerateKey(km.pub.(*ecdsa.PublicKey).Curve, rand.Reader)
+	if err != nil {
+		t.Fatalf("failed to generate unrelated key: %v", err)
+	}
+	wrongVerifier, err := NewLogVerifier(&other.PublicKey)
+	if err != nil {
+		t.Fatalf("NewLogVerifier failed: %v", err)
+	}
+	if err := wrongVerifier.VerifyV1SCTForCertificate(cert, sct); err == nil {
+		t.Fatal("VerifyV1SCTForCertificate unexpectedly succeeded against the wrong log key")
+	}
+}
+
+func TestVerifyV1SCTForPrecertificate(t *testing.T) {
+	cert, err := fixchain.CertificateFromPEM(testonly.PrecertPEMValid)
+	if _, ok := err.(x509.NonFatalErrors); err != nil && !ok {
+		t.Fatalf("failed to set up test precert: %v", err)
+	}
+	issuer, err := fixchain.CertificateFromPEM(testonly.FakeIntermediateCertPem)
+	if err != nil {
+		t.Fatalf("failed to set up test issuer: %v", err)
+	}
+	chain := []*x509.Certificate{cert, issuer}
+
+	km := newRealECDSAKeyManager(t)
+	_, sct, err := signV1SCTForPrecertificate(km, chain, fixedTime)
+	if err != nil {
+		t.Fatalf("signV1SCTForPrecertificate failed: %v", err)
+	}
+
+	v, err := NewLogVerifier(km.pub)
+	if err != nil {
+		t.Fatalf("NewLogVerifier failed: %v", err)
+	}
+	if err := v.VerifyV1SCTForPrecertificate(chain, sct); err != nil {
+		t.Fatalf("VerifyV1SCTForPrecertificate failed on a genuine SCT: %v", err)
+	}
+}
+
+func TestVerifyMerkleTreeLeaf(t *testing.T) {
+	cert, err := fixchain.CertificateFromPEM(testonly.LeafSignedByFakeIntermediateCertPem)
+	if err != nil {
+		t.Fatalf("failed to set up test cert: %v", err)
+	}
+	precert, err := fixchain.CertificateFromPEM(testonly.PrecertPEMValid)
+	if _, ok := err.(x509.NonFatalErrors); err != nil && !ok {
+		t.Fatalf("failed to set up test precert: %v", err)
+	}
+	issuer, err := fixchain.CertificateFromPEM(testonly.FakeIntermediateCertPem)
+	if err != nil {
+		t.Fatalf("failed to set up test issuer: %v", err)
+	}
+	precertChain := []*x509.Certificate{precert, issuer}
+
+	km := newRealRSAKeyManager(t)
+
+	leaf, _, err := signV1SCTForCertificate(km, cert, fixedTime)
+	if err != nil {
+		t.Fatalf("signV1SCTForCertificate failed: %v", err)
+	}
+	if err := VerifyMerkleTreeLeaf(leaf, []*x509.Certificate{cert}); err != nil {
+		t.Fatalf("VerifyMerkleTreeLeaf failed on a genuine cert leaf: %v", err)
+	}
+	if err := VerifyMerkleTreeLeaf(leaf, []*x509.Certificate{precert}); err == nil {
+		t.Fatal("VerifyMerkleTreeLeaf unexpectedly matched the wrong certificate")
+	}
+
+	precertLeaf, _, err := signV1SCTForPrecertificate(km, precertChain, fixedTime)
+	if err != nil {
+		t.Fatalf("signV1SCTForPrecertificate failed: %v", err)
+	}
+	if err := VerifyMerkleTreeLeaf(precertLeaf, precertChain); err != nil {
+		t.Fatalf("VerifyMerkleTreeLeaf failed on a genuine precert leaf: %v", err)
+	}
+}